@@ -4,6 +4,7 @@
 package cmd
 
 import (
+	"github.com/peterjmorgan/gh-context/internal/config"
 	"github.com/peterjmorgan/gh-context/internal/git"
 	"github.com/spf13/cobra"
 )
@@ -11,9 +12,12 @@ import (
 var applyCmd = &cobra.Command{
 	Use:   "apply",
 	Short: "Read .ghcontext in this repo and switch to it",
-	Long:  `Apply the context bound to the current repository by reading .ghcontext and switching.`,
-	Args:  cobra.NoArgs,
-	RunE:  runApply,
+	Long: `Apply the context bound to the current repository by reading .ghcontext and switching.
+Without a .ghcontext file, the first matching auto-binding rule is used (see
+'gh context resolve --help').
+The context's git identity is written to the repository's local git config.`,
+	Args: cobra.NoArgs,
+	RunE: runApply,
 }
 
 func runApply(cmd *cobra.Command, args []string) error {
@@ -27,17 +31,25 @@ func runApply(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Get binding
-	binding, bindErr := git.GetBinding()
-	if bindErr != nil {
-		return bindErr
+	// Get binding, falling back to the auto-binding rules
+	rc, err := resolveRepoContext(root)
+	if err != nil {
+		printErr("%v", err)
+		return err
 	}
-	if binding == "" {
-		printErr("No .ghcontext file found in repository")
+	if rc.Name == "" {
+		printErr("No .ghcontext file found in repository and no rule matches it")
 		printInfo("Create one with: gh context bind <name>")
 		return nil
 	}
 
-	// Use the bound context (reuse the use command logic)
-	return runUse(cmd, []string{binding})
+	if rc.Rule != nil {
+		printInfo("Matched rule: %s", rc.Rule)
+	} else if err := config.RegisterBinding(root, rc.Name); err != nil {
+		// Remember clones whose .ghcontext came from elsewhere (e.g. committed)
+		printErr("Failed to record binding: %v", err)
+	}
+
+	// Use the bound context, keeping its git identity local to this repo
+	return switchContext(rc.Name, switchOptions{Scope: git.ScopeLocal, Verify: true})
 }
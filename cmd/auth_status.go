@@ -5,6 +5,9 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/peterjmorgan/gh-context/internal/auth"
 	"github.com/peterjmorgan/gh-context/internal/config"
@@ -15,12 +18,41 @@ import (
 var authStatusCmd = &cobra.Command{
 	Use:   "auth-status",
 	Short: "Display authentication status for all contexts",
-	Long:  `Show the authentication status for all saved contexts, indicating which are ready to use.`,
-	Args:  cobra.NoArgs,
-	RunE:  runAuthStatus,
+	Long: `Show the authentication status for all saved contexts, indicating which are ready to use.
+
+GH Auth shows whether gh is logged in to the context's account, from the users
+listed in gh's hosts.yml; the file is read once for all contexts.
+
+For contexts with an SSH key, whether the ssh-agent at $SSH_AUTH_SOCK holds it
+is shown too (see 'gh context use --agent').
+
+With --verify, each ssh context's key also connects to git@<host> to show the
+account it logs in as; the contexts are checked in parallel. A key that
+authenticates as someone other than the context's user is flagged as a
+mismatch (see 'gh context verify').`,
+	Args: cobra.NoArgs,
+	RunE: runAuthStatus,
+}
+
+var (
+	authStatusJSON   *jsonOutput
+	authStatusVerify bool
+)
+
+// authStatusProbeTimeout bounds the SSH login checks of --verify, which all
+// run at once.
+const authStatusProbeTimeout = 5 * time.Second
+
+func init() {
+	authStatusCmd.Flags().BoolVar(&authStatusVerify, "verify", false, "Also check which account each SSH key logs in as")
+	authStatusJSON = addJSONFlags(authStatusCmd, contextFieldsWith("active", "sshKeyExists", "sshKeyActive", "sshAgentLoaded", "sshUser", "authenticated"))
 }
 
 func runAuthStatus(cmd *cobra.Command, args []string) error {
+	if authStatusJSON.enabled() {
+		return writeAuthStatusJSON()
+	}
+
 	printPlain("Authentication status for all contexts:")
 	fmt.Println()
 
@@ -39,6 +71,16 @@ func runAuthStatus(cmd *cobra.Command, args []string) error {
 	// Get current SSH config state
 	sshCfg, _ := ssh.ParseConfig("")
 
+	agent, agentErr := ssh.ConnectAgent()
+	if agentErr == nil {
+		defer agent.Close()
+	}
+
+	var logins map[string]sshLogin
+	if authStatusVerify {
+		logins = probeSSHLogins(contexts, authStatusProbeTimeout)
+	}
+
 	for _, ctx := range contexts {
 		indicator := ""
 		if ctx.Name == active {
@@ -60,13 +102,33 @@ func runAuthStatus(cmd *cobra.Command, args []string) error {
 
 			// Check if this key is active in SSH config
 			if sshCfg != nil {
-				activeKey := sshCfg.GetActiveIdentityFile(ctx.Hostname)
-				if activeKey != "" && ssh.ExpandPath(activeKey) == ssh.ExpandPath(ctx.SSHKey) {
+				if sshKeyActive(sshCfg, ctx) {
 					fmt.Printf("  SSH Active: ✅ (currently active in ~/.ssh/config)\n")
 				} else {
 					fmt.Printf("  SSH Active: ❌ (not active in ~/.ssh/config)\n")
 				}
 			}
+
+			if agentErr != nil {
+				fmt.Printf("  SSH Agent: – (no ssh-agent running)\n")
+			} else if loaded, err := agent.HasKey(ctx.SSHKey); err != nil {
+				fmt.Printf("  SSH Agent: – (%v)\n", err)
+			} else if loaded {
+				fmt.Printf("  SSH Agent: ✅ (loaded)\n")
+			} else {
+				fmt.Printf("  SSH Agent: ❌ (not loaded)\n")
+			}
+
+			if r, probed := logins[ctx.Name]; probed {
+				switch {
+				case r.err != nil:
+					fmt.Printf("  SSH Login: ❌ (%v)\n", r.err)
+				case !strings.EqualFold(r.login, ctx.User):
+					fmt.Printf("  SSH Login: ❌ MISMATCH: key authenticates as '%s', not '%s'\n", r.login, ctx.User)
+				default:
+					fmt.Printf("  SSH Login: ✅ (%s)\n", r.login)
+				}
+			}
 		}
 
 		// Check authentication status
@@ -92,3 +154,79 @@ func runAuthStatus(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// writeAuthStatusJSON prints every context with its computed auth state.
+func writeAuthStatusJSON() error {
+	contexts, err := config.ListContexts()
+	if err != nil {
+		return err
+	}
+
+	active, _ := config.GetActive()
+	sshCfg, _ := ssh.ParseConfig("")
+
+	agent, agentErr := ssh.ConnectAgent()
+	if agentErr == nil {
+		defer agent.Close()
+	}
+
+	var logins map[string]sshLogin
+	if authStatusVerify {
+		logins = probeSSHLogins(contexts, authStatusProbeTimeout)
+	}
+
+	data := make([]map[string]interface{}, 0, len(contexts))
+	for _, ctx := range contexts {
+		item := contextData(ctx)
+		item["active"] = ctx.Name == active
+		item["sshKeyExists"] = ctx.SSHKey != "" && ssh.KeyExists(ctx.SSHKey)
+		item["sshKeyActive"] = sshKeyActive(sshCfg, ctx)
+		item["sshAgentLoaded"] = false
+		if agentErr == nil && ctx.SSHKey != "" {
+			item["sshAgentLoaded"], _ = agent.HasKey(ctx.SSHKey)
+		}
+		item["sshUser"] = logins[ctx.Name].login
+		item["authenticated"] = auth.IsUserLoggedIn(ctx.Hostname, ctx.User)
+		data = append(data, item)
+	}
+	return authStatusJSON.write(data)
+}
+
+// sshLogin is the outcome of checking which account a context's key logs in as.
+type sshLogin struct {
+	login string
+	err   error
+}
+
+// probeSSHLogins checks the SSH login of every ssh context in parallel, so
+// the wait is that of the slowest host rather than the sum.
+func probeSSHLogins(contexts []*config.Context, timeout time.Duration) map[string]sshLogin {
+	probe := &ssh.Probe{Timeout: timeout}
+	logins := make(map[string]sshLogin)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, ctx := range contexts {
+		if ctx.Transport != "ssh" || ctx.SSHKey == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(ctx *config.Context) {
+			defer wg.Done()
+			login, err := probe.Identity(ctx.Hostname, ctx.SSHKey)
+			mu.Lock()
+			logins[ctx.Name] = sshLogin{login: login, err: err}
+			mu.Unlock()
+		}(ctx)
+	}
+	wg.Wait()
+	return logins
+}
+
+// sshKeyActive reports whether ~/.ssh/config currently offers the context's key.
+func sshKeyActive(sshCfg *ssh.ConfigFile, ctx *config.Context) bool {
+	if sshCfg == nil || ctx.SSHKey == "" {
+		return false
+	}
+	activeKey := sshCfg.GetActiveIdentityFile(ctx.Hostname)
+	return activeKey != "" && ssh.ExpandPath(activeKey) == ssh.ExpandPath(ctx.SSHKey)
+}
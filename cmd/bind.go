@@ -13,9 +13,23 @@ var bindCmd = &cobra.Command{
 	Use:   "bind <name>",
 	Short: "Write .ghcontext in repo root",
 	Long: `Bind the current repository to a context by creating a .ghcontext file.
-When using shell hooks, the context will be automatically applied when entering this repo.`,
-	Args: cobra.ExactArgs(1),
-	RunE: runBind,
+When using shell hooks, the context will be automatically applied when entering this repo.
+
+With --fix-remotes, remote URLs are also rewritten to the context's transport
+and host after a preview (see 'gh context remotes --help').`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContextNames(1),
+	RunE:              runBind,
+}
+
+var (
+	bindFixRemotes bool
+	bindYes        bool
+)
+
+func init() {
+	bindCmd.Flags().BoolVar(&bindFixRemotes, "fix-remotes", false, "Also rewrite remote URLs to match the context's transport")
+	bindCmd.Flags().BoolVarP(&bindYes, "yes", "y", false, "Rewrite remotes without asking (with --fix-remotes)")
 }
 
 func runBind(cmd *cobra.Command, args []string) error {
@@ -46,9 +60,20 @@ func runBind(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := config.RegisterBinding(root, name); err != nil {
+		printErr("Failed to record binding: %v", err)
+	}
+
 	bindingPath, _ := git.BindingPath()
 	printOk("Bound repo to context '%s' (%s)", name, bindingPath)
 	printInfo("Add .ghcontext to .gitignore if you don't want to commit it")
 
+	if bindFixRemotes {
+		ctx, err := config.Load(name)
+		if err != nil {
+			return err
+		}
+		return fixRemotes(root, ctx, false, bindYes)
+	}
 	return nil
 }
@@ -0,0 +1,170 @@
+// ABOUTME: Bindings command for gh-context - audits the registry of bound repositories
+// ABOUTME: Lists bindings with their status and prunes stale entries
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/git"
+	"github.com/spf13/cobra"
+)
+
+var bindingsCmd = &cobra.Command{
+	Use:   "bindings",
+	Short: "List repositories bound to contexts",
+	Long: `List the repositories bound with 'gh context bind' (or applied with
+'gh context apply'), with the status of each binding:
+
+  ok                the repo's .ghcontext names the recorded context
+  repo-missing      the repository no longer exists
+  context-missing   the context it is bound to has been deleted
+  binding-changed   the repo's .ghcontext was removed or now names another context
+
+Stale entries (anything but ok) can be removed with 'gh context bindings prune'.`,
+	Args: cobra.NoArgs,
+	RunE: runBindings,
+}
+
+var bindingsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Forget stale bindings",
+	Long: `Remove stale entries from the binding registry. Only the registry is
+changed; .ghcontext files are left alone.`,
+	Args: cobra.NoArgs,
+	RunE: runBindingsPrune,
+}
+
+var (
+	bindingsStale    bool
+	bindingsPruneYes bool
+	bindingsJSON     *jsonOutput
+)
+
+// Binding statuses.
+const (
+	bindingOK             = "ok"
+	bindingRepoMissing    = "repo-missing"
+	bindingContextMissing = "context-missing"
+	bindingChanged        = "binding-changed"
+)
+
+func init() {
+	bindingsCmd.Flags().BoolVar(&bindingsStale, "stale", false, "Only show stale bindings")
+	bindingsJSON = addJSONFlags(bindingsCmd, []string{"repo", "context", "status"})
+
+	bindingsPruneCmd.Flags().BoolVarP(&bindingsPruneYes, "yes", "y", false, "Prune without asking")
+	bindingsCmd.AddCommand(bindingsPruneCmd)
+}
+
+// bindingStatus checks a registered binding against the filesystem.
+func bindingStatus(b config.Binding) string {
+	if info, err := os.Stat(b.Repo); err != nil || !info.IsDir() {
+		return bindingRepoMissing
+	}
+	if root, _ := git.RepoRootAt(b.Repo); root == "" {
+		return bindingRepoMissing
+	}
+	if exists, _ := config.Exists(b.Context); !exists {
+		return bindingContextMissing
+	}
+	if current, _ := git.ReadBindingAt(b.Repo); current != b.Context {
+		return bindingChanged
+	}
+	return bindingOK
+}
+
+func runBindings(cmd *cobra.Command, args []string) error {
+	bindings, err := config.ListBindings()
+	if err != nil {
+		return err
+	}
+
+	data := make([]map[string]interface{}, 0, len(bindings))
+	stale := 0
+	for _, b := range bindings {
+		status := bindingStatus(b)
+		if status != bindingOK {
+			stale++
+		} else if bindingsStale {
+			continue
+		}
+		data = append(data, map[string]interface{}{
+			"repo":    b.Repo,
+			"context": b.Context,
+			"status":  status,
+		})
+	}
+
+	if bindingsJSON.enabled() {
+		return bindingsJSON.write(data)
+	}
+
+	if len(data) == 0 {
+		if bindingsStale {
+			printOk("No stale bindings")
+		} else {
+			printInfo("No bound repositories. Bind one with: gh context bind <name>")
+		}
+		return nil
+	}
+
+	printPlain("Bound repositories:")
+	for _, item := range data {
+		marker := ""
+		if item["status"] != bindingOK {
+			marker = fmt.Sprintf("  ⚠️  %s", item["status"])
+		}
+		fmt.Printf("  %s\t%s%s\n", item["context"], item["repo"], marker)
+	}
+
+	if stale > 0 {
+		fmt.Println()
+		printInfo("%d stale binding(s); remove them with: gh context bindings prune", stale)
+	}
+	return nil
+}
+
+func runBindingsPrune(cmd *cobra.Command, args []string) error {
+	bindings, err := config.ListBindings()
+	if err != nil {
+		return err
+	}
+
+	var stale []config.Binding
+	for _, b := range bindings {
+		if status := bindingStatus(b); status != bindingOK {
+			printPlain("  %s\t%s  (%s)", b.Context, b.Repo, status)
+			stale = append(stale, b)
+		}
+	}
+	if len(stale) == 0 {
+		printOk("No stale bindings")
+		return nil
+	}
+
+	if !bindingsPruneYes {
+		if !canConfirm() {
+			printErr("Refusing to prune without confirmation; rerun with --yes")
+			return fmt.Errorf("confirmation required")
+		}
+		answer, err := confirm(fmt.Sprintf("Forget these %d binding(s)?", len(stale)), true)
+		if err != nil {
+			return err
+		}
+		if !answer {
+			printInfo("Nothing pruned")
+			return nil
+		}
+	}
+
+	for _, b := range stale {
+		if err := config.UnregisterBinding(b.Repo); err != nil {
+			return err
+		}
+	}
+	printOk("Pruned %d stale binding(s)", len(stale))
+	return nil
+}
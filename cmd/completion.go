@@ -0,0 +1,182 @@
+// ABOUTME: Completion command for gh-context - shell completion as "gh context"
+// ABOUTME: Also holds the completion functions for context names and flag values
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion <shell>",
+	Short: "Print a shell completion script for gh context",
+	Long: `Print a completion script for bash, zsh, fish or powershell that completes
+'gh context' subcommands, context names and flag values.
+
+gh does not pass completion requests on to extensions, so the script completes
+the whole gh command line: 'gh context ...' is answered by this binary and
+everything else by gh itself. Load it after gh's own completion, and regenerate
+it if the binary moves.
+
+Examples:
+  gh context completion bash >> ~/.bashrc
+  gh context completion zsh > "${fpath[1]}/_gh"
+  gh context completion fish > ~/.config/fish/completions/gh.fish
+  gh context completion powershell >> $PROFILE`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+	RunE:      runCompletion,
+}
+
+// completionRoutes patches cobra's generated scripts for "gh": after the line
+// that builds the request for "gh __complete", requests for "gh context ..."
+// are sent to this binary instead. %[1]s is this binary, quoted for the shell.
+var completionRoutes = map[string]struct{ after, route string }{
+	"bash": {
+		after: `    requestComp="${words[0]} __complete ${args[*]}"` + "\n",
+		route: `    if [[ ${#args[@]} -gt 1 && ${args[0]} == context ]]; then
+        requestComp="%[1]s __complete ${args[*]:1}"
+    fi
+`,
+	},
+	"zsh": {
+		after: `    requestComp="${words[1]} __complete ${words[2,-1]}"` + "\n",
+		route: `    if (( ${#words} > 2 )) && [[ ${words[2]} == context ]]; then
+        requestComp="%[1]s __complete ${words[3,-1]}"
+    fi
+`,
+	},
+	"fish": {
+		after: `    set -l requestComp "GH_ACTIVE_HELP=0 $args[1] __complete $args[2..-1] $lastArg"` + "\n",
+		route: `    if test (count $args) -ge 2; and test "$args[2]" = context
+        set requestComp "GH_ACTIVE_HELP=0 %[1]s __complete $args[3..-1] $lastArg"
+    end
+`,
+	},
+	"powershell": {
+		after: `    $RequestComp="$Program __complete $Arguments"` + "\n",
+		route: `    if ($Arguments -match '^context(\s+(.*))?$' -and ($Matches[1] -or $WordToComplete -eq "")) {
+        $RequestComp="%[1]s __complete $($Matches[2])"
+    }
+`,
+	},
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	shell := args[0]
+	route, ok := completionRoutes[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell: %s (supported: bash, zsh, fish, powershell)", shell)
+	}
+	sh, err := lookupShell(shell)
+	if err != nil {
+		return err
+	}
+
+	// Generate gh's script; the completions themselves come from __complete
+	gh := &cobra.Command{Use: "gh"}
+	var buf bytes.Buffer
+	switch shell {
+	case "bash":
+		err = gh.GenBashCompletionV2(&buf, true)
+	case "zsh":
+		err = gh.GenZshCompletion(&buf)
+	case "fish":
+		err = gh.GenFishCompletion(&buf, true)
+	case "powershell":
+		err = gh.GenPowerShellCompletionWithDesc(&buf)
+	}
+	if err != nil {
+		return err
+	}
+
+	script := buf.String()
+	if !strings.Contains(script, route.after) {
+		return fmt.Errorf("cannot add gh context routing to the %s completion script", shell)
+	}
+	script = strings.Replace(script, route.after, route.after+fmt.Sprintf(route.route, sh.command()), 1)
+
+	_, err = fmt.Print(script)
+	return err
+}
+
+// completeContextNames completes context names for commands taking up to max
+// of them (0 for any number), skipping names already given.
+func completeContextNames(max int) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if max > 0 && len(args) >= max {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		names, err := config.List()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		var matches []string
+		for _, name := range names {
+			if strings.HasPrefix(name, toComplete) && !contains(args, name) {
+				matches = append(matches, name)
+			}
+		}
+		return matches, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeValues completes a flag from a fixed list.
+func completeValues(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp)
+}
+
+// completeHostnames completes hostnames of existing contexts, plus github.com.
+func completeHostnames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	hosts := []string{"github.com"}
+	contexts, _, _ := config.LoadAll()
+	for _, ctx := range contexts {
+		if !contains(hosts, ctx.Hostname) {
+			hosts = append(hosts, ctx.Hostname)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSSHKeys completes private keys in ~/.ssh, recognised by their .pub
+// files, as ~/.ssh/... paths.
+func completeSSHKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	pubs, _ := filepath.Glob(filepath.Join(home, ".ssh", "*.pub"))
+	var keys []string
+	for _, pub := range pubs {
+		private := strings.TrimSuffix(pub, ".pub")
+		if info, err := os.Stat(private); err == nil && info.Mode().IsRegular() {
+			keys = append(keys, "~/.ssh/"+filepath.Base(private))
+		}
+	}
+	if len(keys) == 0 {
+		return nil, cobra.ShellCompDirectiveDefault // Fall back to files
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
+// registerContextFlagCompletions adds value completion for the context field
+// flags shared by new and edit.
+func registerContextFlagCompletions(cmd *cobra.Command) {
+	_ = cmd.RegisterFlagCompletionFunc("hostname", completeHostnames)
+	_ = cmd.RegisterFlagCompletionFunc("transport", completeValues("ssh", "https"))
+	_ = cmd.RegisterFlagCompletionFunc("ssh-key", completeSSHKeys)
+	_ = cmd.RegisterFlagCompletionFunc("ssh-strategy", completeValues(config.SSHStrategyIdentity, config.SSHStrategyAlias))
+	_ = cmd.RegisterFlagCompletionFunc("signing-format", completeValues("gpg", "ssh"))
+}
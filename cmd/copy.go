@@ -0,0 +1,43 @@
+// ABOUTME: Copy command for gh-context - duplicates a saved context
+// ABOUTME: Useful as a starting point for a similar account; creates its SSH alias if needed
+
+package cmd
+
+import (
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+var copyCmd = &cobra.Command{
+	Use:     "copy <src> <dst>",
+	Aliases: []string{"cp"},
+	Short:   "Copy a context under a new name",
+	Long: `Save a copy of a context under a new name. The copy is not activated and
+no .ghcontext files are changed. For contexts using the alias strategy, the
+copy gets its own managed SSH alias.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeContextNames(1),
+	RunE:              runCopy,
+}
+
+func runCopy(cmd *cobra.Command, args []string) error {
+	src, dst := args[0], args[1]
+
+	ctx, err := config.Copy(src, dst)
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	if ctx.UsesSSHAlias() {
+		if err := ensureSSHAlias(ctx); err != nil {
+			printErr("Failed to add SSH alias: %v", err)
+		} else {
+			printOk("Added SSH alias 'Host %s' to ~/.ssh/config", ssh.AliasName(ctx.Hostname, ctx.Name))
+		}
+	}
+
+	printOk("Copied context '%s' to '%s' → %s", src, dst, ctx)
+	return nil
+}
@@ -14,8 +14,15 @@ import (
 var currentCmd = &cobra.Command{
 	Use:   "current",
 	Short: "Show active context and repo-bound context",
-	Long:  `Display the currently active context and any repository-specific context binding.`,
-	RunE:  runCurrent,
+	Long: `Display the currently active context and any repository-specific context binding,
+from .ghcontext or from a matching auto-binding rule.`,
+	RunE: runCurrent,
+}
+
+var currentJSON *jsonOutput
+
+func init() {
+	currentJSON = addJSONFlags(currentCmd, contextFieldsWith("active", "repoBinding", "repoBindingPath", "repoRule"))
 }
 
 func runCurrent(cmd *cobra.Command, args []string) error {
@@ -24,6 +31,10 @@ func runCurrent(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if currentJSON.enabled() {
+		return writeCurrentJSON(active)
+	}
+
 	if active == "" {
 		printPlain("No active context")
 	} else {
@@ -40,6 +51,9 @@ func runCurrent(cmd *cobra.Command, args []string) error {
 		}
 
 		printPlain("Active: %s (%s@%s, %s%s)", ctx.Name, ctx.User, ctx.Hostname, ctx.Transport, sshInfo)
+		if ctx.HasGitIdentity() {
+			printPlain("Git identity: %s", gitIdentity(ctx))
+		}
 	}
 
 	// Check for repo binding
@@ -49,15 +63,52 @@ func runCurrent(cmd *cobra.Command, args []string) error {
 	}
 
 	if root != "" {
-		binding, err := git.GetBinding()
+		rc, err := resolveRepoContext(root)
 		if err != nil {
+			printErr("%v", err)
 			return err
 		}
-		if binding != "" {
-			bindingPath, _ := git.BindingPath()
-			printPlain("Repo-bound: %s (in %s)", binding, bindingPath)
+		if rc.BindingPath != "" {
+			printPlain("Repo-bound: %s (in %s)", rc.Name, rc.BindingPath)
+		} else if rc.Rule != nil {
+			printPlain("Repo-bound: %s (rule on line %d: %s)", rc.Name, rc.Rule.Line, rc.Rule)
 		}
 	}
 
 	return nil
 }
+
+// writeCurrentJSON prints the active context and repo binding as one object.
+// Context fields are empty when no context is active or its file is missing.
+func writeCurrentJSON(active string) error {
+	ctx := &config.Context{}
+	if active != "" {
+		if loaded, err := config.Load(active); err == nil {
+			ctx = loaded
+		}
+	}
+
+	data := contextData(ctx)
+	data["active"] = active
+	data["repoBinding"] = ""
+	data["repoBindingPath"] = ""
+	data["repoRule"] = ""
+
+	root, err := git.RepoRoot()
+	if err != nil {
+		return err
+	}
+	if root != "" {
+		rc, err := resolveRepoContext(root)
+		if err != nil {
+			return err
+		}
+		data["repoBinding"] = rc.Name
+		data["repoBindingPath"] = rc.BindingPath
+		if rc.Rule != nil {
+			data["repoRule"] = rc.Rule.String()
+		}
+	}
+
+	return currentJSON.write(data)
+}
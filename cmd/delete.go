@@ -4,7 +4,11 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
 	"github.com/spf13/cobra"
 )
 
@@ -12,22 +16,41 @@ var deleteCmd = &cobra.Command{
 	Use:     "delete <name>",
 	Aliases: []string{"rm", "remove"},
 	Short:   "Remove a saved context",
-	Long:    `Delete a saved context. Clears the active pointer if the deleted context was active.`,
-	Args:    cobra.ExactArgs(1),
-	RunE:    runDelete,
+	Long: `Delete a saved context. Clears the active pointer if the deleted context was active,
+and removes the context's managed SSH alias if it has one.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContextNames(1),
+	RunE:              runDelete,
 }
 
 func runDelete(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
+	if !confirmDeleteBound(name) {
+		printInfo("Kept context '%s'", name)
+		return nil
+	}
+
 	// Check if we need to clear active pointer
 	active, _ := config.GetActive()
 	willClearActive := active == name
 
+	// Load first so the SSH alias can be cleaned up after the file is gone
+	ctx, _ := config.Load(name)
+
 	if err := config.Delete(name); err != nil {
 		return err
 	}
 
+	if ctx != nil {
+		removed, err := removeSSHAlias(ctx)
+		if err != nil {
+			printErr("Failed to remove SSH alias: %v", err)
+		} else if removed {
+			printInfo("Removed SSH alias 'Host %s' from ~/.ssh/config", ssh.AliasName(ctx.Hostname, ctx.Name))
+		}
+	}
+
 	if willClearActive {
 		printInfo("Cleared active context pointer")
 	}
@@ -35,3 +58,31 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	printOk("Deleted context '%s'", name)
 	return nil
 }
+
+// confirmDeleteBound warns about repositories still bound to name and, on a
+// terminal, asks whether to go ahead. Returns false to keep the context.
+func confirmDeleteBound(name string) bool {
+	bound, err := config.BindingsFor(name)
+	if err != nil || len(bound) == 0 {
+		return true
+	}
+
+	printErr("%d repositor%s still bound to '%s':", len(bound), plural(len(bound), "y is", "ies are"), name)
+	for _, b := range bound {
+		fmt.Fprintf(os.Stderr, "  %s\n", b.Repo)
+	}
+
+	if !canConfirm() {
+		return true
+	}
+	answer, err := confirm(fmt.Sprintf("Delete '%s' anyway?", name), false)
+	return err == nil && answer
+}
+
+// plural picks the singular or plural suffix for n.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
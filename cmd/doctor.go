@@ -0,0 +1,114 @@
+// ABOUTME: Doctor command for gh-context - end-to-end setup diagnostics
+// ABOUTME: Runs checks over contexts, SSH keys, ssh-agent, SSH config and gh tokens
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/peterjmorgan/gh-context/internal/doctor"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor [name...]",
+	Short: "Diagnose context, SSH and authentication problems",
+	Long: `Run diagnostic checks for all contexts (or the named ones):
+
+  active-pointer       active context points to an existing context
+  repo-binding         .ghcontext in this repo names an existing context
+  rules                the auto-binding rules file parses and names existing contexts
+  context-file         the context file parses and passes validation
+  ssh-key-exists       the context's SSH key file exists
+  ssh-key-permissions  the key is not readable by group/others (0600)
+  ssh-public-key       the .pub file next to the key exists
+  ssh-agent-loaded     the key is loaded in ssh-agent
+  ssh-config-match     ~/.ssh/config has a block for the host listing the key
+  gh-token             gh has a token stored for the context's user
+  ssh-identity         the key authenticates as the context's user (--verify-ssh)
+
+Exits non-zero if any error-severity check fails. With --json, each result has
+the fields id, context, severity, status, message and hint.`,
+	ValidArgsFunction: completeContextNames(0),
+	RunE:              runDoctor,
+}
+
+var (
+	doctorVerifySSH bool
+	doctorJSON      *jsonOutput
+)
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorVerifySSH, "verify-ssh", false, "Connect to git@<host> to confirm each key's GitHub account")
+	doctorJSON = addJSONFlags(doctorCmd, []string{"id", "context", "severity", "status", "message", "hint"})
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	results, err := doctor.Run(doctor.Options{
+		Contexts:  args,
+		VerifySSH: doctorVerifySSH,
+	})
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	errCount, warnCount := doctor.Summary(results)
+
+	if doctorJSON.enabled() {
+		data := make([]map[string]interface{}, 0, len(results))
+		for _, r := range results {
+			data = append(data, map[string]interface{}{
+				"id":       r.ID,
+				"context":  r.Context,
+				"severity": string(r.Severity),
+				"status":   string(r.Status),
+				"message":  r.Message,
+				"hint":     r.Hint,
+			})
+		}
+		if err := doctorJSON.write(data); err != nil {
+			return err
+		}
+	} else {
+		printDoctorResults(results)
+		fmt.Println()
+		if errCount == 0 && warnCount == 0 {
+			printOk("No problems found")
+		} else {
+			printPlain("%d error(s), %d warning(s)", errCount, warnCount)
+		}
+	}
+
+	if errCount > 0 {
+		return &exitCodeError{code: 1}
+	}
+	return nil
+}
+
+func printDoctorResults(results []doctor.Result) {
+	lastContext := ""
+	for _, r := range results {
+		// Per-context results are grouped under a header
+		if !doctor.IsGlobal(r.ID) && r.Context != lastContext {
+			fmt.Println()
+			printPlain("Context: %s", r.Context)
+			lastContext = r.Context
+		}
+
+		icon := "✅"
+		switch {
+		case r.Status == doctor.StatusSkip:
+			icon = "➖"
+		case r.Failed() && r.Severity == doctor.SeverityError:
+			icon = "❌"
+		case r.Failed():
+			icon = "⚠️ "
+		}
+
+		fmt.Printf("  %s [%s] %s\n", icon, r.ID, r.Message)
+		if r.Failed() && r.Hint != "" {
+			fmt.Printf("     → %s\n", r.Hint)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// ABOUTME: Edit command for gh-context - modifies a saved context in place
+// ABOUTME: Per-field flags or $EDITOR; re-applies the context if it is active
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	ghConfig "github.com/cli/go-gh/v2/pkg/config"
+	"github.com/kballard/go-shellquote"
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/git"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Modify an existing context",
+	Long: `Change fields of a saved context. With no field flags, the context opens in
+your editor (GH_EDITOR, gh's "editor" setting, VISUAL or EDITOR, in that order).
+
+Edits are validated like 'gh context new': the transport must be valid and the
+SSH key must exist. If the edited context is active, it is re-applied so the
+SSH config, git URL rewrites and git identity match the new settings.
+
+Examples:
+  gh context edit work --ssh-key ~/.ssh/id_work_ed25519
+  gh context edit work --user jdoe-acme --git-email jdoe@acme.com
+  gh context edit work --git-name ""      # clear a field
+  gh context edit work                    # open in $EDITOR`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContextNames(1),
+	RunE:              runEdit,
+}
+
+var (
+	editHostname         string
+	editUser             string
+	editTransport        string
+	editSSHKey           string
+	editSSHStrategy      string
+	editGitName          string
+	editGitEmail         string
+	editGitSigningKey    string
+	editGitSigningFormat string
+)
+
+func init() {
+	editCmd.Flags().StringVar(&editHostname, "hostname", "", "GitHub hostname")
+	editCmd.Flags().StringVar(&editUser, "user", "", "GitHub username")
+	editCmd.Flags().StringVar(&editTransport, "transport", "", "Transport protocol (ssh or https)")
+	editCmd.Flags().StringVar(&editSSHKey, "ssh-key", "", "Path to SSH key")
+	editCmd.Flags().StringVar(&editSSHStrategy, "ssh-strategy", "", "How to switch SSH keys (identity or alias)")
+	editCmd.Flags().StringVar(&editGitName, "git-name", "", "Git user.name to use with this context")
+	editCmd.Flags().StringVar(&editGitEmail, "git-email", "", "Git user.email to use with this context")
+	editCmd.Flags().StringVar(&editGitSigningKey, "signing-key", "", "Git signing key (GPG key ID or SSH public key path)")
+	editCmd.Flags().StringVar(&editGitSigningFormat, "signing-format", "", "Git signing format (gpg or ssh)")
+	registerContextFlagCompletions(editCmd)
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	old, err := config.Load(name)
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	var ctx *config.Context
+	if cmd.Flags().NFlag() == 0 {
+		ctx, err = editInEditor(old)
+	} else {
+		ctx, err = editWithFlags(cmd, old)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *ctx == *old {
+		printInfo("No changes to context '%s'", name)
+		return nil
+	}
+
+	if err := validateContext(ctx); err != nil {
+		return err
+	}
+	if err := ctx.Save(); err != nil {
+		return err
+	}
+	printOk("Updated context '%s' → %s", name, ctx)
+
+	return reapplyContext(old, ctx)
+}
+
+// editWithFlags applies the field flags that were given to a copy of old.
+func editWithFlags(cmd *cobra.Command, old *config.Context) (*config.Context, error) {
+	ctx := *old
+	fields := []struct {
+		flag  string
+		value string
+		field *string
+	}{
+		{"hostname", editHostname, &ctx.Hostname},
+		{"user", editUser, &ctx.User},
+		{"transport", editTransport, &ctx.Transport},
+		{"ssh-key", editSSHKey, &ctx.SSHKey},
+		{"ssh-strategy", editSSHStrategy, &ctx.SSHStrategy},
+		{"git-name", editGitName, &ctx.GitName},
+		{"git-email", editGitEmail, &ctx.GitEmail},
+		{"signing-key", editGitSigningKey, &ctx.GitSigningKey},
+		{"signing-format", editGitSigningFormat, &ctx.GitSigningFormat},
+	}
+	for _, f := range fields {
+		if cmd.Flags().Changed(f.flag) {
+			*f.field = f.value
+		}
+	}
+
+	if ctx.SSHStrategy == config.SSHStrategyIdentity {
+		ctx.SSHStrategy = "" // Identity is the default and is not written
+	}
+	if ctx.Transport == "https" && old.Transport != "https" && !cmd.Flags().Changed("ssh-key") {
+		ctx.SSHKey = "" // Switching to https drops the key unless one was given
+		ctx.SSHStrategy = ""
+	}
+	return &ctx, nil
+}
+
+// editInEditor opens the context file format in the user's editor until it
+// parses or the user gives up.
+func editInEditor(old *config.Context) (*config.Context, error) {
+	if !canConfirm() {
+		printErr("No field flags given and no terminal to open an editor on")
+		printInfo("Use flags such as --user or --ssh-key, see 'gh context edit --help'")
+		return nil, fmt.Errorf("nothing to edit")
+	}
+
+	data, err := old.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "gh-context-"+old.Name+"-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	tmp.Close()
+
+	for {
+		if err := os.WriteFile(tmp.Name(), data, 0600); err != nil {
+			return nil, err
+		}
+		if err := runEditor(tmp.Name()); err != nil {
+			printErr("Editor failed: %v", err)
+			return nil, err
+		}
+
+		edited, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(edited)) == 0 {
+			printInfo("Empty file, edit cancelled")
+			return old, nil
+		}
+
+		ctx, parseErr := config.Parse(old.Name+".ctx", old.Name, edited)
+		if parseErr == nil {
+			return ctx, nil
+		}
+
+		printErr("%v", parseErr)
+		again, err := confirm("Edit again?", true)
+		if err != nil || !again {
+			return nil, parseErr
+		}
+		data = edited
+	}
+}
+
+// runEditor opens path in the user's editor, like gh does.
+func runEditor(path string) error {
+	args, err := shellquote.Split(editorCommand())
+	if err != nil || len(args) == 0 {
+		return fmt.Errorf("cannot parse editor command %q", editorCommand())
+	}
+
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// editorCommand returns the editor gh itself would use.
+func editorCommand() string {
+	if editor := os.Getenv("GH_EDITOR"); editor != "" {
+		return editor
+	}
+	if cfg, err := ghConfig.Read(nil); err == nil {
+		if editor, err := cfg.Get([]string{"editor"}); err == nil && editor != "" {
+			return editor
+		}
+	}
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := os.Getenv(env); editor != "" {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "nano"
+}
+
+// reapplyContext brings SSH aliases, git URL rewrites and the git identity in
+// line with an edited context. Only an active context is switched to again.
+func reapplyContext(old, ctx *config.Context) error {
+	// The old alias goes away if the strategy or host changed; a new key is
+	// picked up by ensureSSHAlias or activateSSHAlias below
+	oldAlias := ssh.AliasName(old.Hostname, old.Name)
+	if old.UsesSSHAlias() && (!ctx.UsesSSHAlias() || ssh.AliasName(ctx.Hostname, ctx.Name) != oldAlias) {
+		if _, err := removeSSHAlias(old); err != nil {
+			printErr("Failed to remove SSH alias 'Host %s': %v", oldAlias, err)
+		}
+	}
+
+	active, _ := config.GetActive()
+	if active != ctx.Name {
+		if ctx.UsesSSHAlias() {
+			if err := ensureSSHAlias(ctx); err != nil {
+				printErr("Failed to update SSH alias: %v", err)
+			}
+		}
+		return nil
+	}
+
+	// Re-apply in the git config the identity was written to. A repo's local
+	// config is only reachable from inside it
+	recorded, _ := config.GetActiveScope()
+	opts := switchOptions{Scope: git.ScopeGlobal, Verify: true}
+	if scope, ok := reachableScope(recorded, git.ScopeGlobal); ok {
+		opts.Scope = scope
+	} else {
+		opts.KeepIdentity = true
+	}
+
+	// switchContext only reverts the identity of a different outgoing context
+	if old.HasGitIdentity() && !opts.KeepIdentity {
+		if err := revertGitIdentity(opts.Scope, old); err != nil {
+			printErr("Failed to revert the old git identity: %v", err)
+		}
+	}
+
+	fmt.Println()
+	printInfo("'%s' is active; re-applying it", ctx.Name)
+	if err := switchContext(ctx.Name, opts); err != nil {
+		return err
+	}
+	if opts.KeepIdentity && (old.HasGitIdentity() || ctx.HasGitIdentity()) {
+		printInfo("Its git identity is in the local config of %s; run 'gh context apply' there to update it", recorded.Repo)
+	}
+	return nil
+}
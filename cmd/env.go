@@ -0,0 +1,302 @@
+// ABOUTME: Env command for gh-context - prints shell exports for a context
+// ABOUTME: Activates a context for one shell without touching global state
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/peterjmorgan/gh-context/internal/auth"
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/git"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env [name]",
+	Short: "Print environment exports that activate a context in this shell only",
+	Long: `Print shell statements that activate a context for the current shell only.
+
+Unlike 'use', this does not modify ~/.ssh/config, gh auth or the active context.
+It sets GH_TOKEN, GH_HOST, GIT_SSH_COMMAND and the git author variables, so two
+terminals can use two accounts at the same time. For https contexts it also
+points git's HTTPS credentials for the host at gh, as the context's user, through
+GIT_CONFIG_COUNT/GIT_CONFIG_KEY_n/GIT_CONFIG_VALUE_n (git 2.31 or later).
+
+Examples:
+  eval "$(gh context env work)"
+  eval "$(gh context env --unset)"
+  gh context env work --shell fish | source
+  gh context env work --shell powershell | Invoke-Expression`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeContextNames(1),
+	RunE:              runEnv,
+}
+
+var (
+	envShell string
+	envUnset bool
+)
+
+func init() {
+	envCmd.Flags().StringVar(&envShell, "shell", "bash", "Shell syntax to emit (bash, zsh, fish, powershell, pwsh)")
+	_ = envCmd.RegisterFlagCompletionFunc("shell", completeValues("bash", "zsh", "fish", "powershell", "pwsh"))
+	envCmd.Flags().BoolVar(&envUnset, "unset", false, "Print statements that clear a previously exported context")
+}
+
+// envVar is a single environment variable assignment.
+type envVar struct {
+	Name  string
+	Value string
+}
+
+// envChange moves an environment onto a context: the variables to set and the
+// ones to remove.
+type envChange struct {
+	Set   []envVar
+	Unset []string
+}
+
+// contextEnvNames lists every variable contextEnv may set, used for --unset.
+// The GIT_CONFIG_n parameters are shared with the caller, see gitConfigEnv.
+var contextEnvNames = []string{
+	"GH_CONTEXT",
+	"GH_HOST",
+	"GH_TOKEN",
+	"GH_ENTERPRISE_TOKEN",
+	"GIT_SSH_COMMAND",
+	"GIT_AUTHOR_NAME",
+	"GIT_COMMITTER_NAME",
+	"GIT_AUTHOR_EMAIL",
+	"GIT_COMMITTER_EMAIL",
+}
+
+// gitConfigBaseVar records how many GIT_CONFIG_n parameters belonged to the
+// caller before a context appended its own, so the next switch drops only those.
+const gitConfigBaseVar = "GH_CONTEXT_GIT_CONFIG_BASE"
+
+// staleEnvNames returns the contextEnvNames that vars leaves unset, so a
+// variable exported for an earlier context can't outlive the switch.
+func staleEnvNames(vars []envVar) []string {
+	set := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		set[v.Name] = true
+	}
+	var stale []string
+	for _, name := range contextEnvNames {
+		if !set[name] {
+			stale = append(stale, name)
+		}
+	}
+	return stale
+}
+
+func runEnv(cmd *cobra.Command, args []string) error {
+	if _, err := lookupShell(envShell); err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	if envUnset {
+		if len(args) > 0 {
+			err := fmt.Errorf("--unset does not take a context name")
+			printErr("%v", err)
+			return err
+		}
+		return printEnv(clearContextEnv(os.Environ()))
+	}
+
+	if len(args) == 0 {
+		err := fmt.Errorf("context name required (or use --unset)")
+		printErr("%v", err)
+		return err
+	}
+
+	ctx, err := config.Load(args[0])
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	// Clears what an earlier 'env' exported and this context doesn't replace
+	change, err := contextEnv(ctx, os.Environ())
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+	return printEnv(change)
+}
+
+// printEnv prints the unsets, then the exports, in the --shell syntax.
+func printEnv(change envChange) error {
+	for _, name := range change.Unset {
+		line, err := formatUnset(envShell, name)
+		if err != nil {
+			return err
+		}
+		fmt.Println(line)
+	}
+	for _, v := range change.Set {
+		line, err := formatExport(envShell, v.Name, v.Value)
+		if err != nil {
+			return err
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// contextEnv builds the change that moves environ onto ctx for a single process
+// tree. A missing token is reported but not fatal, so SSH-only setups still work.
+func contextEnv(ctx *config.Context, environ []string) (envChange, error) {
+	vars := []envVar{
+		{"GH_CONTEXT", ctx.Name},
+		{"GH_HOST", ctx.Hostname},
+	}
+
+	token, err := auth.Token(ctx.Hostname, ctx.User)
+	if err != nil {
+		// Both go to stderr: stdout is evaluated by the shell
+		printErr("%v", err)
+		fmt.Fprintf(os.Stderr, "• Log in with: gh auth login --hostname %s --username %s\n", ctx.Hostname, ctx.User)
+	} else {
+		vars = append(vars, envVar{auth.TokenEnvVar(ctx.Hostname), token})
+	}
+
+	if ctx.Transport == "ssh" && ctx.SSHKey != "" {
+		key := ssh.ExpandPath(ctx.SSHKey)
+		vars = append(vars, envVar{"GIT_SSH_COMMAND", fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes", shellQuote(key))})
+	}
+
+	if ctx.GitName != "" {
+		vars = append(vars, envVar{"GIT_AUTHOR_NAME", ctx.GitName}, envVar{"GIT_COMMITTER_NAME", ctx.GitName})
+	}
+	if ctx.GitEmail != "" {
+		vars = append(vars, envVar{"GIT_AUTHOR_EMAIL", ctx.GitEmail}, envVar{"GIT_COMMITTER_EMAIL", ctx.GitEmail})
+	}
+
+	var entries [][2]string
+	if ctx.Transport == "https" {
+		entries = credentialEntries(ctx)
+	}
+	gitConfig := gitConfigEnv(environ, entries)
+
+	return envChange{
+		Set:   append(vars, gitConfig.Set...),
+		Unset: append(staleEnvNames(vars), gitConfig.Unset...),
+	}, nil
+}
+
+// clearContextEnv is the change that removes everything contextEnv may have
+// added to environ.
+func clearContextEnv(environ []string) envChange {
+	gitConfig := gitConfigEnv(environ, nil)
+	return envChange{
+		Set:   gitConfig.Set,
+		Unset: append(append([]string{}, contextEnvNames...), gitConfig.Unset...),
+	}
+}
+
+// credentialEntries is the environment form of what 'use' writes to git config
+// for https contexts: gh as the only credential helper for the host, asked for
+// the context's user. gh answers with the GH_TOKEN exported next to it.
+func credentialEntries(ctx *config.Context) [][2]string {
+	helper, err := auth.GitCredentialHelper()
+	if err != nil {
+		printErr("Cannot set up git credentials: %v", err)
+		return nil
+	}
+
+	return [][2]string{
+		{git.CredentialKey(ctx.Hostname, "helper"), ""}, // Clears keychain and store helpers
+		{git.CredentialKey(ctx.Hostname, "helper"), helper},
+		{git.CredentialKey(ctx.Hostname, "username"), ctx.User},
+	}
+}
+
+// gitConfigEnv appends entries to the git config parameters in environ, after
+// the caller's own. Entries an earlier context appended are replaced, and are
+// dropped when entries is empty.
+func gitConfigEnv(environ []string, entries [][2]string) envChange {
+	count, _ := strconv.Atoi(envValue(environ, "GIT_CONFIG_COUNT"))
+	base := count
+	marked := envValue(environ, gitConfigBaseVar) != ""
+	if marked {
+		if b, err := strconv.Atoi(envValue(environ, gitConfigBaseVar)); err == nil && b >= 0 && b <= count {
+			base = b
+		}
+	}
+
+	var change envChange
+	total := base + len(entries)
+	switch {
+	case total == 0 && count > 0:
+		change.Unset = append(change.Unset, "GIT_CONFIG_COUNT")
+	case total != count || len(entries) > 0:
+		change.Set = append(change.Set, envVar{"GIT_CONFIG_COUNT", strconv.Itoa(total)})
+	}
+	for i, e := range entries {
+		change.Set = append(change.Set,
+			envVar{fmt.Sprintf("GIT_CONFIG_KEY_%d", base+i), e[0]},
+			envVar{fmt.Sprintf("GIT_CONFIG_VALUE_%d", base+i), e[1]})
+	}
+	for i := total; i < count; i++ {
+		change.Unset = append(change.Unset, fmt.Sprintf("GIT_CONFIG_KEY_%d", i), fmt.Sprintf("GIT_CONFIG_VALUE_%d", i))
+	}
+
+	if len(entries) > 0 {
+		change.Set = append(change.Set, envVar{gitConfigBaseVar, strconv.Itoa(base)})
+	} else if marked {
+		change.Unset = append(change.Unset, gitConfigBaseVar)
+	}
+	return change
+}
+
+// envValue returns the value of name in an environment list.
+func envValue(environ []string, name string) string {
+	for _, kv := range environ {
+		if key, value, ok := strings.Cut(kv, "="); ok && key == name {
+			return value
+		}
+	}
+	return ""
+}
+
+// formatExport renders an environment assignment in the given shell's syntax.
+func formatExport(shell, name, value string) (string, error) {
+	sh, err := lookupShell(shell)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(sh.export, name, sh.quote(value)), nil
+}
+
+// formatUnset renders removal of an environment variable in the given shell's syntax.
+func formatUnset(shell, name string) (string, error) {
+	sh, err := lookupShell(shell)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(sh.unset, name), nil
+}
+
+// shellQuote single-quotes a value for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes a value for fish, which escapes \ and ' inside quotes.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "'", `\'`)
+	return "'" + s + "'"
+}
+
+// powershellQuote single-quotes a value for PowerShell.
+func powershellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+var testEntries = [][2]string{
+	{"credential.https://github.com.helper", ""},
+	{"credential.https://github.com.helper", "!gh auth git-credential"},
+	{"credential.https://github.com.username", "alice"},
+}
+
+func TestGitConfigEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		environ   []string
+		entries   [][2]string
+		wantSet   map[string]string
+		wantUnset []string
+	}{
+		{
+			name:    "nothing to add or clear",
+			environ: []string{"HOME=/home/alice"},
+		},
+		{
+			name:    "caller's parameters kept without entries",
+			environ: []string{"GIT_CONFIG_COUNT=2", "GIT_CONFIG_KEY_1=a.b", "GIT_CONFIG_VALUE_1=c"},
+		},
+		{
+			name:    "entries start at zero",
+			entries: testEntries,
+			wantSet: map[string]string{
+				"GIT_CONFIG_COUNT":   "3",
+				"GIT_CONFIG_KEY_0":   "credential.https://github.com.helper",
+				"GIT_CONFIG_VALUE_0": "",
+				"GIT_CONFIG_KEY_1":   "credential.https://github.com.helper",
+				"GIT_CONFIG_VALUE_1": "!gh auth git-credential",
+				"GIT_CONFIG_KEY_2":   "credential.https://github.com.username",
+				"GIT_CONFIG_VALUE_2": "alice",
+				gitConfigBaseVar:     "0",
+			},
+		},
+		{
+			name:    "entries follow the caller's parameters",
+			environ: []string{"GIT_CONFIG_COUNT=4"},
+			entries: testEntries[2:],
+			wantSet: map[string]string{
+				"GIT_CONFIG_COUNT":   "5",
+				"GIT_CONFIG_KEY_4":   "credential.https://github.com.username",
+				"GIT_CONFIG_VALUE_4": "alice",
+				gitConfigBaseVar:     "4",
+			},
+		},
+		{
+			name:    "entries of an earlier context replaced",
+			environ: []string{"GIT_CONFIG_COUNT=5", gitConfigBaseVar + "=2"},
+			entries: testEntries[2:],
+			wantSet: map[string]string{
+				"GIT_CONFIG_COUNT":   "3",
+				"GIT_CONFIG_KEY_2":   "credential.https://github.com.username",
+				"GIT_CONFIG_VALUE_2": "alice",
+				gitConfigBaseVar:     "2",
+			},
+			wantUnset: []string{"GIT_CONFIG_KEY_3", "GIT_CONFIG_VALUE_3", "GIT_CONFIG_KEY_4", "GIT_CONFIG_VALUE_4"},
+		},
+		{
+			name:      "entries of an earlier context dropped back to the caller's",
+			environ:   []string{"GIT_CONFIG_COUNT=2", gitConfigBaseVar + "=1"},
+			wantSet:   map[string]string{"GIT_CONFIG_COUNT": "1"},
+			wantUnset: []string{"GIT_CONFIG_KEY_1", "GIT_CONFIG_VALUE_1", gitConfigBaseVar},
+		},
+		{
+			name:      "only an earlier context's parameters",
+			environ:   []string{"GIT_CONFIG_COUNT=1", gitConfigBaseVar + "=0"},
+			wantUnset: []string{"GIT_CONFIG_COUNT", "GIT_CONFIG_KEY_0", "GIT_CONFIG_VALUE_0", gitConfigBaseVar},
+		},
+		{
+			name:    "out of range marker ignored",
+			environ: []string{"GIT_CONFIG_COUNT=1", gitConfigBaseVar + "=7"},
+			entries: testEntries[2:],
+			wantSet: map[string]string{
+				"GIT_CONFIG_COUNT":   "2",
+				"GIT_CONFIG_KEY_1":   "credential.https://github.com.username",
+				"GIT_CONFIG_VALUE_1": "alice",
+				gitConfigBaseVar:     "1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change := gitConfigEnv(tt.environ, tt.entries)
+
+			set := map[string]string{}
+			for _, v := range change.Set {
+				set[v.Name] = v.Value
+			}
+			if len(set) != len(tt.wantSet) || (len(set) > 0 && !reflect.DeepEqual(set, tt.wantSet)) {
+				t.Errorf("Set = %v, want %v", set, tt.wantSet)
+			}
+
+			unset := append([]string{}, change.Unset...)
+			want := append([]string{}, tt.wantUnset...)
+			sort.Strings(unset)
+			sort.Strings(want)
+			if len(unset) != len(want) || (len(unset) > 0 && !reflect.DeepEqual(unset, want)) {
+				t.Errorf("Unset = %v, want %v", unset, want)
+			}
+		})
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	base := []string{
+		"HOME=/home/alice",
+		"GH_TOKEN=old",
+		"GIT_CONFIG_COUNT=2",
+		"GIT_CONFIG_KEY_0=user.signingkey",
+		"GIT_CONFIG_VALUE_0=ABC",
+		"GIT_CONFIG_KEY_1=stale",
+	}
+	change := envChange{
+		Set:   []envVar{{"GH_CONTEXT", "work"}, {"GIT_CONFIG_COUNT", "1"}},
+		Unset: []string{"GH_TOKEN", "GIT_CONFIG_KEY_1"},
+	}
+
+	got := mergeEnv(base, change)
+	want := []string{
+		"HOME=/home/alice",
+		"GIT_CONFIG_KEY_0=user.signingkey",
+		"GIT_CONFIG_VALUE_0=ABC",
+		"GH_CONTEXT=work",
+		"GIT_CONFIG_COUNT=1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestStaleEnvNames(t *testing.T) {
+	vars := []envVar{{"GH_CONTEXT", "b"}, {"GH_HOST", "github.com"}}
+	stale := staleEnvNames(vars)
+
+	for _, name := range []string{"GH_TOKEN", "GIT_AUTHOR_NAME", "GIT_SSH_COMMAND"} {
+		found := false
+		for _, s := range stale {
+			found = found || s == name
+		}
+		if !found {
+			t.Errorf("staleEnvNames() = %v, missing %s", stale, name)
+		}
+	}
+	for _, s := range stale {
+		if s == "GH_CONTEXT" || s == "GH_HOST" {
+			t.Errorf("staleEnvNames() = %v, includes %s which is set", stale, s)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// ABOUTME: Exec command for gh-context - runs a command under a context
+// ABOUTME: Sets the context's environment for one process tree only
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <name> -- <command> [args...]",
+	Short: "Run a command with a context's credentials without switching globally",
+	Long: `Run a single command with the context's GH_TOKEN, GH_HOST, GIT_SSH_COMMAND and
+git identity set in its environment. Global state (~/.ssh/config, gh auth, the
+active context) is left untouched.
+
+Examples:
+  gh context exec work -- git push
+  gh context exec personal -- gh repo list`,
+	Args: cobra.MinimumNArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return completeContextNames(1)(cmd, args, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveDefault // The command to run
+	},
+	RunE: runExec,
+}
+
+func init() {
+	// Everything after the context name belongs to the child command
+	execCmd.Flags().SetInterspersed(false)
+}
+
+// exitCodeError carries a child process exit code back to main.
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// ExitCode returns the process exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	var codeErr *exitCodeError
+	if errors.As(err, &codeErr) {
+		return codeErr.code
+	}
+	return 1
+}
+
+func runExec(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	command := args[1:]
+
+	// Flag parsing stops at the context name, so "--" arrives as an argument
+	if command[0] == "--" {
+		command = command[1:]
+	}
+	if len(command) == 0 {
+		printErr("No command given")
+		return fmt.Errorf("usage: gh context exec <name> -- <command> [args...]")
+	}
+
+	ctx, err := config.Load(name)
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	change, err := contextEnv(ctx, os.Environ())
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	child := exec.Command(command[0], command[1:]...)
+	child.Env = mergeEnv(os.Environ(), change)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	// The child receives terminal signals itself; don't die before it does
+	signal.Ignore(os.Interrupt)
+
+	if err := child.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return &exitCodeError{code: childExitCode(exitErr)}
+		}
+		printErr("%v", err)
+		return err
+	}
+	return nil
+}
+
+// childExitCode returns the code a shell would report for the child: its exit
+// status, or 128 plus the signal that killed it.
+func childExitCode(exitErr *exec.ExitError) int {
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
+
+// mergeEnv returns base with change applied. Token variables of other
+// contexts are in change.Unset, so they can't leak through.
+func mergeEnv(base []string, change envChange) []string {
+	drop := make(map[string]bool, len(change.Unset)+len(change.Set))
+	for _, name := range change.Unset {
+		drop[name] = true
+	}
+	for _, v := range change.Set {
+		drop[v.Name] = true
+	}
+
+	env := make([]string, 0, len(base)+len(change.Set))
+	for _, kv := range base {
+		key, _, _ := strings.Cut(kv, "=")
+		if drop[key] {
+			continue
+		}
+		env = append(env, kv)
+	}
+	for _, v := range change.Set {
+		env = append(env, v.Name+"="+v.Value)
+	}
+	return env
+}
@@ -0,0 +1,152 @@
+// ABOUTME: Export command for gh-context - writes contexts to a portable bundle
+// ABOUTME: Produces YAML or JSON with ~-relative key paths and optional repo bindings
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/fileutil"
+	"github.com/peterjmorgan/gh-context/internal/git"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [names...]",
+	Short: "Export contexts to a YAML or JSON bundle",
+	Long: `Export all contexts (or the named ones) to a single document that
+'gh context import' can recreate on another machine.
+
+Tokens are never exported; log in with 'gh auth login' on the new machine.
+SSH key paths under your home directory are written as ~/... paths.
+
+Use --bindings to include the registered repository bindings of the exported
+contexts (see 'gh context bindings'), and --bind-repo to add other repos: each
+repo's .ghcontext is recorded under the context it names, if that context is
+being exported.
+
+Examples:
+  gh context export > contexts.yaml
+  gh context export work personal --format json --output contexts.json
+  gh context export --bindings
+  gh context export --bind-repo ~/src/work-app --bind-repo ~/src/blog`,
+	ValidArgsFunction: completeContextNames(0),
+	RunE:              runExport,
+}
+
+var (
+	exportFormat   string
+	exportOutput   string
+	exportBindings []string
+	exportRegistry bool
+)
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", "yaml", "Output format (yaml or json)")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to a file instead of stdout")
+	exportCmd.Flags().StringArrayVar(&exportBindings, "bind-repo", nil, "Include the .ghcontext binding of this repo (repeatable)")
+	exportCmd.Flags().BoolVar(&exportRegistry, "bindings", false, "Include the registered bindings of the exported contexts")
+	_ = exportCmd.RegisterFlagCompletionFunc("format", completeValues("yaml", "json"))
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	switch exportFormat {
+	case "yaml", "json":
+		// Valid
+	default:
+		err := fmt.Errorf("format must be 'yaml' or 'json', got: %s", exportFormat)
+		printErr("%v", err)
+		return err
+	}
+
+	var contexts []*config.Context
+	if len(args) == 0 {
+		all, err := config.ListContexts()
+		if err != nil {
+			printErr("%v", err)
+			return err
+		}
+		contexts = all
+	} else {
+		for _, name := range args {
+			ctx, err := config.Load(name)
+			if err != nil {
+				printErr("%v", err)
+				return err
+			}
+			contexts = append(contexts, ctx)
+		}
+	}
+
+	if len(contexts) == 0 {
+		printErr("No contexts to export")
+		return fmt.Errorf("no contexts")
+	}
+
+	bundle := &config.Bundle{Version: config.BundleVersion}
+	index := make(map[string]int, len(contexts))
+	for _, ctx := range contexts {
+		index[ctx.Name] = len(bundle.Contexts)
+		bundle.Contexts = append(bundle.Contexts, config.ExportContext(ctx))
+	}
+
+	dirs := exportBindings
+	if exportRegistry {
+		registered, err := config.ListBindings()
+		if err != nil {
+			printErr("%v", err)
+			return err
+		}
+		for _, b := range registered {
+			if _, ok := index[b.Context]; ok {
+				dirs = append(dirs, b.Repo)
+			}
+		}
+	}
+
+	// Messages go to stderr so they don't end up in a bundle written to stdout
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		root, err := git.RepoRootAt(ssh.ExpandPath(dir))
+		if err != nil || root == "" {
+			printErr("Skipping %s: not a Git repository", dir)
+			continue
+		}
+		binding, err := git.ReadBindingAt(root)
+		if err != nil {
+			printErr("%v", err)
+			return err
+		}
+		i, ok := index[binding]
+		if binding == "" || !ok {
+			printErr("Skipping %s: not bound to an exported context", dir)
+			continue
+		}
+		if seen[root] {
+			continue
+		}
+		seen[root] = true
+		bundle.Contexts[i].Bindings = append(bundle.Contexts[i].Bindings, config.HomeRelative(root))
+	}
+
+	data, err := bundle.Marshal(exportFormat)
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	if exportOutput == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if err := fileutil.WriteFileAtomic(exportOutput, data, 0644); err != nil {
+		printErr("Failed to write %s: %v", exportOutput, err)
+		return err
+	}
+	printOk("Exported %d context(s) to %s", len(bundle.Contexts), exportOutput)
+	return nil
+}
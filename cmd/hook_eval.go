@@ -0,0 +1,187 @@
+// ABOUTME: Hook-eval command for gh-context - the fast path behind shell-hook
+// ABOUTME: Resolves the repo's context in-process and prints only the statements the shell needs
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/git"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+var hookEvalCmd = &cobra.Command{
+	Use:   "hook-eval",
+	Short: "Print the shell statements for a prompt hook",
+	Long: `Print the shell statements that bring a shell in line with the context bound to
+--pwd, for the hooks generated by 'gh context shell-hook'. Prints nothing when
+nothing needs to change. Messages go to stderr so stdout can be evaluated.
+
+The repo root and .ghcontext are found without running git. Rule lookups (see
+'gh context resolve --help') are cached per repo until the rules file, the
+repo's git config or ~/.ssh/config changes.`,
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runHookEval,
+}
+
+// hookAutoVar marks context variables exported by the env-mode hook, so it
+// only clears what it set itself.
+const hookAutoVar = "__GH_CONTEXT_AUTO"
+
+var (
+	hookEvalShell string
+	hookEvalMode  string
+	hookEvalPwd   string
+)
+
+func init() {
+	hookEvalCmd.Flags().StringVar(&hookEvalShell, "shell", "bash", "Shell syntax to emit (bash, zsh, fish, powershell, pwsh)")
+	hookEvalCmd.Flags().StringVar(&hookEvalMode, "mode", "use", "How the hook applies contexts (use or env)")
+	hookEvalCmd.Flags().StringVar(&hookEvalPwd, "pwd", ".", "Directory the shell is in")
+}
+
+func runHookEval(cmd *cobra.Command, args []string) error {
+	sh, err := lookupShell(hookEvalShell)
+	if err != nil {
+		return err
+	}
+	if err := checkHookMode(hookEvalMode); err != nil {
+		return err
+	}
+
+	name, err := hookContext(hookEvalPwd)
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	if hookEvalMode == "env" {
+		return hookEvalEnv(sh, name)
+	}
+
+	if name == "" {
+		return nil
+	}
+	if active, _ := config.GetActive(); active == name {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "• Auto-applying gh context: %s\n", name)
+	// Runs before the prompt, so skip the SSH round trip of the login check
+	fmt.Printf("%s use --no-verify %s\n", sh.command(), sh.quote(name))
+	return nil
+}
+
+// hookEvalEnv prints the exports of the bound context, or clears the ones an
+// earlier prompt exported once the shell leaves the repo. Variables of the
+// previous context that the new one doesn't set are cleared too.
+func hookEvalEnv(sh *shellSyntax, name string) error {
+	if name == "" {
+		if os.Getenv(hookAutoVar) == "" {
+			return nil
+		}
+		change := clearContextEnv(os.Environ())
+		change.Unset = append(change.Unset, hookAutoVar)
+		printShellEnv(sh, change)
+		return nil
+	}
+
+	if os.Getenv("GH_CONTEXT") == name {
+		return nil
+	}
+
+	ctx, err := config.Load(name)
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "• Applying gh context to this shell: %s\n", name)
+	change, err := contextEnv(ctx, os.Environ())
+	if err != nil {
+		return err
+	}
+	change.Set = append(change.Set, envVar{hookAutoVar, "1"})
+	printShellEnv(sh, change)
+	return nil
+}
+
+// printShellEnv prints the unsets, then the exports, in sh's syntax.
+func printShellEnv(sh *shellSyntax, change envChange) {
+	for _, name := range change.Unset {
+		fmt.Printf(sh.unset+"\n", name)
+	}
+	for _, v := range change.Set {
+		fmt.Printf(sh.export+"\n", v.Name, sh.quote(v.Value))
+	}
+}
+
+// hookContext returns the context bound to the repo containing dir. Rule
+// lookups go through the hook cache; .ghcontext is cheap enough to read.
+func hookContext(dir string) (string, error) {
+	return cachedRepoContext(dir, false)
+}
+
+// promptContext is hookContext for prompts: only files are read, so the
+// origin comes from the repo's config file instead of git, and the cache is
+// left for the hooks to fill.
+func promptContext(dir string) (string, error) {
+	return cachedRepoContext(dir, true)
+}
+
+func cachedRepoContext(dir string, readOnly bool) (string, error) {
+	root := git.FindRepoRoot(dir)
+	if root == "" {
+		return "", nil
+	}
+
+	binding, err := git.ReadBindingAt(root)
+	if err != nil || binding != "" {
+		return binding, err
+	}
+
+	rulesPath, err := config.RulesFile()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(rulesPath); err != nil {
+		return "", nil // No rules
+	}
+
+	stamp := fmt.Sprintf("%s=%s %s %s", rulesPath, fileStamp(rulesPath),
+		fileStamp(git.ConfigFileAt(root)), fileStamp(ssh.DefaultConfigPath()))
+
+	cache := config.LoadHookCache()
+	if name, ok := cache.Lookup(root, stamp); ok {
+		return name, nil
+	}
+
+	if readOnly {
+		rc, err := resolveRepoContextWith(root, git.ReadOriginAt)
+		if err != nil {
+			return "", err
+		}
+		return rc.Name, nil
+	}
+
+	rc, err := resolveRepoContext(root)
+	if err != nil {
+		return "", err
+	}
+	cache.Store(root, stamp, rc.Name)
+	_ = cache.Save() // Best effort; the next prompt just resolves again
+	return rc.Name, nil
+}
+
+// fileStamp identifies the current version of a file by size and mtime.
+func fileStamp(path string) string {
+	info, err := os.Stat(filepath.Clean(path))
+	if err != nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d@%d", info.Size(), info.ModTime().UnixNano())
+}
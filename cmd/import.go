@@ -0,0 +1,203 @@
+// ABOUTME: Import command for gh-context - recreates contexts from an export bundle
+// ABOUTME: Handles name conflicts (skip/overwrite/rename), dry runs and repo bindings
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/git"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import contexts from an export bundle",
+	Long: `Recreate contexts from a bundle written by 'gh context export'.
+Use - to read the bundle from stdin.
+
+When a context with the same name already exists:
+  skip       leave the existing context alone (default)
+  overwrite  replace it with the imported one
+  rename     import under a new name (name-2, name-3, ...)
+
+Bindings in the bundle are written to .ghcontext in each listed repo that
+exists on this machine. Tokens are not part of bundles; log in with
+'gh auth login' for each imported account.
+
+Examples:
+  gh context import contexts.yaml --dry-run
+  gh context import contexts.yaml --on-conflict rename`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+var (
+	importOnConflict string
+	importDryRun     bool
+)
+
+// Conflict policies for import.
+const (
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictRename    = "rename"
+)
+
+func init() {
+	importCmd.Flags().StringVar(&importOnConflict, "on-conflict", conflictSkip, "What to do when a context exists (skip, overwrite or rename)")
+	_ = importCmd.RegisterFlagCompletionFunc("on-conflict", completeValues(conflictSkip, conflictOverwrite, conflictRename))
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show what would be imported without changing anything")
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	switch importOnConflict {
+	case conflictSkip, conflictOverwrite, conflictRename:
+		// Valid
+	default:
+		err := fmt.Errorf("on-conflict must be 'skip', 'overwrite' or 'rename', got: %s", importOnConflict)
+		printErr("%v", err)
+		return err
+	}
+
+	data, err := readImportFile(args[0])
+	if err != nil {
+		printErr("Cannot read %s: %v", args[0], err)
+		return err
+	}
+
+	bundle, err := config.ParseBundle(data)
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	// Validate everything up front so a bad entry doesn't leave a half import
+	for _, entry := range bundle.Contexts {
+		if err := entry.Context().Validate(); err != nil {
+			printErr("Invalid context '%s' in %s: %v", entry.Name, args[0], err)
+			return err
+		}
+	}
+
+	existing, err := config.List()
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		taken[name] = true
+	}
+
+	active, _ := config.GetActive()
+
+	imported := 0
+	for _, entry := range bundle.Contexts {
+		ctx := entry.Context()
+
+		if taken[ctx.Name] {
+			switch importOnConflict {
+			case conflictSkip:
+				printInfo("Skipping '%s': context already exists", ctx.Name)
+				continue
+			case conflictRename:
+				original := ctx.Name
+				ctx.Name = uniqueContextName(original, taken)
+				printInfo("'%s' already exists; importing as '%s'", original, ctx.Name)
+			case conflictOverwrite:
+				printInfo("'%s' already exists; overwriting it", ctx.Name)
+			}
+		}
+		taken[ctx.Name] = true
+
+		if importDryRun {
+			printOk("Would import '%s' → %s", ctx.Name, ctx)
+		} else {
+			if err := importContext(ctx, active); err != nil {
+				printErr("Failed to import '%s': %v", ctx.Name, err)
+				return err
+			}
+			printOk("Imported '%s' → %s", ctx.Name, ctx)
+		}
+
+		if ctx.SSHKey != "" && !ssh.KeyExists(ctx.SSHKey) {
+			printInfo("SSH key %s is not on this machine yet; copy it before using '%s'", ctx.SSHKey, ctx.Name)
+		}
+
+		importBindings(ctx.Name, entry.Bindings)
+		imported++
+	}
+
+	fmt.Println()
+	if importDryRun {
+		printPlain("%d context(s) would be imported (dry run, nothing changed)", imported)
+	} else {
+		printPlain("Imported %d context(s). Log in to each account with: gh auth login --hostname <host>", imported)
+	}
+	return nil
+}
+
+// importContext saves an imported context and sets up its SSH alias.
+func importContext(ctx *config.Context, active string) error {
+	if err := ctx.Save(); err != nil {
+		return err
+	}
+
+	if ctx.UsesSSHAlias() {
+		if err := ensureSSHAlias(ctx); err != nil {
+			printErr("Failed to add SSH alias: %v", err)
+		}
+	}
+
+	if ctx.Name == active {
+		printInfo("'%s' is the active context; run 'gh context use %s' to apply the imported settings", ctx.Name, ctx.Name)
+	}
+	return nil
+}
+
+// importBindings writes .ghcontext into each bound repo that exists here.
+func importBindings(name string, bindings []string) {
+	for _, dir := range bindings {
+		path := ssh.ExpandPath(dir)
+		root, err := git.RepoRootAt(path)
+		if err != nil || root == "" {
+			printInfo("Skipping binding %s: not a Git repository on this machine", dir)
+			continue
+		}
+
+		if importDryRun {
+			printOk("Would bind %s to '%s'", root, name)
+			continue
+		}
+		if err := git.WriteBindingAt(root, name); err != nil {
+			printErr("Failed to bind %s: %v", root, err)
+			continue
+		}
+		if err := config.RegisterBinding(root, name); err != nil {
+			printErr("Failed to record binding: %v", err)
+		}
+		printOk("Bound %s to '%s'", root, name)
+	}
+}
+
+// uniqueContextName returns name-2, name-3, ... whichever is first free.
+func uniqueContextName(name string, taken map[string]bool) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+func readImportFile(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
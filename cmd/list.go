@@ -18,13 +18,37 @@ var listCmd = &cobra.Command{
 	RunE:    runList,
 }
 
+var listJSON *jsonOutput
+
+func init() {
+	listJSON = addJSONFlags(listCmd, contextFieldsWith("active"))
+}
+
 func runList(cmd *cobra.Command, args []string) error {
-	contexts, err := config.ListContexts()
+	contexts, loadErrs, err := config.LoadAll()
 	if err != nil {
 		return err
 	}
+	for _, loadErr := range loadErrs {
+		printErr("Skipping invalid context: %v", loadErr)
+	}
+
+	if listJSON.enabled() {
+		active, err := config.GetActive()
+		if err != nil {
+			return err
+		}
+
+		data := make([]map[string]interface{}, 0, len(contexts))
+		for _, ctx := range contexts {
+			item := contextData(ctx)
+			item["active"] = ctx.Name == active
+			data = append(data, item)
+		}
+		return listJSON.write(data)
+	}
 
-	if len(contexts) == 0 {
+	if len(contexts) == 0 && len(loadErrs) == 0 {
 		printInfo("No contexts found. Create one with: gh context new --from-current --name <name>")
 		return nil
 	}
@@ -21,10 +21,23 @@ var newCmd = &cobra.Command{
 For SSH transport, the SSH key is required. When using --from-current, it will
 detect the currently active SSH key from your ~/.ssh/config file.
 
+With --generate-key, a new ed25519 keypair is created at ~/.ssh/id_<name> (or
+the --ssh-key path), added to the Host block for the hostname in ~/.ssh/config,
+and uploaded to the account with 'gh ssh-key add'. Uploading needs the
+admin:public_key scope on that account's gh login.
+
+SSH strategies:
+  identity  Comment/uncomment IdentityFile lines in your Host block (default)
+  alias     Add a "Host <hostname>-<name>" alias to a gh-context managed section
+            of ~/.ssh/config and rewrite git URLs to it on switch
+
 Examples:
   gh context new --from-current --name work
   gh context new --from-current --name personal --ssh-key ~/.ssh/id_personal
-  gh context new --hostname github.com --user myuser --ssh-key ~/.ssh/id_mykey --name mycontext`,
+  gh context new --hostname github.com --user myuser --ssh-key ~/.ssh/id_mykey --name mycontext
+  gh context new --hostname github.com --user myuser --ssh-key ~/.ssh/id_mykey --name mycontext --ssh-strategy alias
+  gh context new --from-current --name work --git-name "Jane Doe" --git-email jane@work.com
+  gh context new --hostname github.com --user myuser --name mycontext --generate-key`,
 	RunE: runNew,
 }
 
@@ -35,6 +48,14 @@ var (
 	newUser        string
 	newTransport   string
 	newSSHKey      string
+	newGenerateKey bool
+
+	newGitName          string
+	newGitEmail         string
+	newGitSigningKey    string
+	newGitSigningFormat string
+
+	newSSHStrategy string
 )
 
 func init() {
@@ -44,6 +65,13 @@ func init() {
 	newCmd.Flags().StringVar(&newUser, "user", "", "GitHub username")
 	newCmd.Flags().StringVar(&newTransport, "transport", "ssh", "Transport protocol (ssh or https)")
 	newCmd.Flags().StringVar(&newSSHKey, "ssh-key", "", "Path to SSH key (e.g., ~/.ssh/id_personal)")
+	newCmd.Flags().BoolVar(&newGenerateKey, "generate-key", false, "Generate a new SSH key, add it to ~/.ssh/config and upload it to the account")
+	newCmd.Flags().StringVar(&newSSHStrategy, "ssh-strategy", config.SSHStrategyIdentity, "How to switch SSH keys (identity or alias)")
+	newCmd.Flags().StringVar(&newGitName, "git-name", "", "Git user.name to use with this context")
+	newCmd.Flags().StringVar(&newGitEmail, "git-email", "", "Git user.email to use with this context")
+	newCmd.Flags().StringVar(&newGitSigningKey, "signing-key", "", "Git signing key (GPG key ID or SSH public key path)")
+	newCmd.Flags().StringVar(&newGitSigningFormat, "signing-format", "", "Git signing format (gpg or ssh)")
+	registerContextFlagCompletions(newCmd)
 
 	newCmd.MarkFlagRequired("name")
 }
@@ -63,6 +91,11 @@ func runNew(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("context '%s' already exists", newName)
 	}
 
+	if newGenerateKey && newTransport != "ssh" {
+		printErr("--generate-key requires ssh transport")
+		return fmt.Errorf("--generate-key requires ssh transport")
+	}
+
 	var hostname, user, sshKey string
 
 	if newFromCurrent {
@@ -90,7 +123,7 @@ func runNew(cmd *cobra.Command, args []string) error {
 
 		// Get SSH key - from flag or detect from current config
 		sshKey = newSSHKey
-		if sshKey == "" && newTransport == "ssh" {
+		if sshKey == "" && newTransport == "ssh" && !newGenerateKey {
 			// Try to detect from SSH config
 			sshCfg, err := ssh.ParseConfig("")
 			if err == nil {
@@ -111,12 +144,8 @@ func runNew(cmd *cobra.Command, args []string) error {
 		sshKey = newSSHKey
 	}
 
-	// Validate transport
-	switch newTransport {
-	case "ssh", "https":
-		// Valid
-	default:
-		return fmt.Errorf("transport must be 'ssh' or 'https', got: %s", newTransport)
+	if newGenerateKey && sshKey == "" {
+		sshKey = ssh.DefaultKeyPath(newName)
 	}
 
 	// For SSH transport, require SSH key
@@ -126,25 +155,59 @@ func runNew(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("SSH key required")
 	}
 
-	// Validate SSH key exists if provided
-	if sshKey != "" && !ssh.KeyExists(sshKey) {
-		printErr("SSH key file not found: %s", ssh.ExpandPath(sshKey))
-		return fmt.Errorf("SSH key not found")
+	if newGitSigningFormat != "" && newGitSigningKey == "" {
+		return fmt.Errorf("--signing-format requires --signing-key")
 	}
 
 	// Create and save context
 	ctx := &config.Context{
-		Name:      newName,
-		Hostname:  hostname,
-		User:      user,
-		Transport: newTransport,
-		SSHKey:    sshKey,
+		Name:             newName,
+		Hostname:         hostname,
+		User:             user,
+		Transport:        newTransport,
+		SSHKey:           sshKey,
+		GitName:          newGitName,
+		GitEmail:         newGitEmail,
+		GitSigningKey:    newGitSigningKey,
+		GitSigningFormat: newGitSigningFormat,
+	}
+	if newSSHStrategy != config.SSHStrategyIdentity {
+		ctx.SSHStrategy = newSSHStrategy
+	}
+
+	if newGenerateKey {
+		// Check everything else first so a bad flag doesn't leave a stray key
+		if err := ctx.Validate(); err != nil {
+			printErr("%v", err)
+			return err
+		}
+		if err := ssh.GenerateKey(sshKey, fmt.Sprintf("%s@%s", user, hostname), canConfirm()); err != nil {
+			printErr("%v", err)
+			return err
+		}
+		printOk("Generated SSH key %s", sshKey)
+	}
+
+	if err := validateContext(ctx); err != nil {
+		return err
 	}
 
 	if err := ctx.Save(); err != nil {
 		return err
 	}
 
+	if newGenerateKey {
+		registerGeneratedKey(ctx)
+	}
+
+	if ctx.UsesSSHAlias() {
+		if err := ensureSSHAlias(ctx); err != nil {
+			printErr("Failed to add SSH alias: %v", err)
+		} else {
+			printOk("Added SSH alias 'Host %s' to ~/.ssh/config", ssh.AliasName(ctx.Hostname, ctx.Name))
+		}
+	}
+
 	sshInfo := ""
 	if sshKey != "" {
 		sshInfo = fmt.Sprintf(", key=%s", sshKey)
@@ -153,3 +216,55 @@ func runNew(cmd *cobra.Command, args []string) error {
 	printOk("Created context '%s' → %s@%s (%s%s)", newName, user, hostname, newTransport, sshInfo)
 	return nil
 }
+
+// validateContext runs the checks shared by new and edit: the fields must be
+// consistent and, for ssh transport, the SSH key must exist on this machine.
+func validateContext(ctx *config.Context) error {
+	if ctx.Transport == "ssh" && ctx.SSHKey == "" {
+		printErr("SSH key is required for SSH transport")
+		printInfo("Provide one with --ssh-key PATH")
+		return fmt.Errorf("SSH key required")
+	}
+
+	if ctx.SSHKey != "" && !ssh.KeyExists(ctx.SSHKey) {
+		printErr("SSH key file not found: %s", ssh.ExpandPath(ctx.SSHKey))
+		return fmt.Errorf("SSH key not found")
+	}
+
+	if err := ctx.Validate(); err != nil {
+		printErr("%v", err)
+		return err
+	}
+	return nil
+}
+
+// registerGeneratedKey adds a freshly generated key to ~/.ssh/config and
+// uploads it to the context's account. Failures are reported with the command
+// to retry, since the context itself is already saved.
+func registerGeneratedKey(ctx *config.Context) {
+	if !ctx.UsesSSHAlias() {
+		// Commented out until the context is used; alias contexts get their own block
+		err := ssh.Update("", func(sshCfg *ssh.ConfigFile) (bool, error) {
+			return true, sshCfg.AddIdentityFile(ctx.Hostname, ctx.SSHKey, false)
+		})
+		if err != nil {
+			printErr("Failed to add %s to ~/.ssh/config: %v", ctx.SSHKey, err)
+		} else {
+			printOk("Added %s to the 'Host %s' block in ~/.ssh/config", ctx.SSHKey, ctx.Hostname)
+		}
+	}
+
+	pubKey := ssh.PublicKeyPath(ctx.SSHKey)
+	title := "gh-context " + ctx.Name
+	if host, err := os.Hostname(); err == nil {
+		title = fmt.Sprintf("%s (%s)", title, host)
+	}
+
+	if err := auth.AddSSHKey(ctx.Hostname, ctx.User, pubKey, title); err != nil {
+		printErr("Failed to upload the public key to %s@%s: %v", ctx.User, ctx.Hostname, err)
+		printInfo("If the token lacks the scope: gh auth refresh --hostname %s --scopes admin:public_key", ctx.Hostname)
+		printInfo("Then retry as %s: gh ssh-key add %s --title %s", ctx.User, shellQuote(pubKey), shellQuote(title))
+		return
+	}
+	printOk("Uploaded %s to %s@%s", pubKey, ctx.User, ctx.Hostname)
+}
@@ -0,0 +1,211 @@
+// ABOUTME: Structured output for gh-context commands (--json, --jq, --template)
+// ABOUTME: Mirrors gh's own flags using go-gh's jq, template and jsonpretty packages
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/jq"
+	"github.com/cli/go-gh/v2/pkg/jsonpretty"
+	"github.com/cli/go-gh/v2/pkg/template"
+	"github.com/cli/go-gh/v2/pkg/term"
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// contextFields are the JSON fields every context-based schema starts from.
+var contextFields = []string{
+	"name", "hostname", "user", "transport", "sshKey", "sshStrategy",
+	"gitName", "gitEmail", "gitSigningKey", "gitSigningFormat",
+}
+
+// contextFieldsWith returns contextFields followed by a command's computed fields.
+func contextFieldsWith(extra ...string) []string {
+	fields := make([]string, 0, len(contextFields)+len(extra))
+	fields = append(fields, contextFields...)
+	return append(fields, extra...)
+}
+
+// contextData returns the contextFields of ctx as a JSON object.
+func contextData(ctx *config.Context) map[string]interface{} {
+	strategy := ctx.SSHStrategy
+	if strategy == "" && ctx.Transport == "ssh" {
+		strategy = config.SSHStrategyIdentity
+	}
+
+	return map[string]interface{}{
+		"name":             ctx.Name,
+		"hostname":         ctx.Hostname,
+		"user":             ctx.User,
+		"transport":        ctx.Transport,
+		"sshKey":           ctx.SSHKey,
+		"sshStrategy":      strategy,
+		"gitName":          ctx.GitName,
+		"gitEmail":         ctx.GitEmail,
+		"gitSigningKey":    ctx.GitSigningKey,
+		"gitSigningFormat": ctx.GitSigningFormat,
+	}
+}
+
+// jsonOutput holds a command's --json, --jq and --template flags.
+type jsonOutput struct {
+	available []string
+	fields    []string
+	jq        string
+	template  string
+}
+
+// addJSONFlags registers --json, --jq and --template on cmd. fields lists the
+// names that may be passed to --json.
+func addJSONFlags(cmd *cobra.Command, fields []string) *jsonOutput {
+	o := &jsonOutput{available: fields}
+
+	f := cmd.Flags()
+	f.StringSliceVar(&o.fields, "json", nil, "Output JSON with the specified `fields`")
+	f.StringVarP(&o.jq, "jq", "q", "", "Filter JSON output using a jq `expression`")
+	f.StringVarP(&o.template, "template", "t", "", "Format JSON output using a Go template; see \"gh help formatting\"")
+
+	// Like gh, a bare --json lists the fields that can be requested
+	cmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		if c == cmd && strings.Contains(err.Error(), "flag needs an argument: --json") {
+			printErr("Specify one or more comma-separated fields for --json:")
+			for _, name := range o.sortedFields() {
+				fmt.Fprintf(os.Stderr, "  %s\n", name)
+			}
+			return err
+		}
+		printErr("%v", err)
+		return err
+	})
+
+	// Complete field names after the last comma
+	_ = cmd.RegisterFlagCompletionFunc("json", func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		prefix := toComplete[:strings.LastIndex(toComplete, ",")+1]
+		chosen := strings.Split(prefix, ",")
+		var names []string
+		for _, name := range o.sortedFields() {
+			if !contains(chosen, name) {
+				names = append(names, prefix+name)
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+	})
+
+	cmd.PreRunE = func(c *cobra.Command, args []string) error {
+		return o.validate(c)
+	}
+
+	return o
+}
+
+// enabled reports whether JSON output was requested.
+func (o *jsonOutput) enabled() bool {
+	return len(o.fields) > 0
+}
+
+func (o *jsonOutput) sortedFields() []string {
+	names := append([]string(nil), o.available...)
+	sort.Strings(names)
+	return names
+}
+
+func (o *jsonOutput) validate(cmd *cobra.Command) error {
+	if !o.enabled() {
+		for _, flag := range []string{"jq", "template"} {
+			if cmd.Flags().Changed(flag) {
+				printErr("Cannot use --%s without specifying --json", flag)
+				return fmt.Errorf("--%s requires --json", flag)
+			}
+		}
+		return nil
+	}
+
+	if o.jq != "" && o.template != "" {
+		printErr("Only one of --jq or --template may be used")
+		return fmt.Errorf("--jq and --template are mutually exclusive")
+	}
+
+	for _, field := range o.fields {
+		if !contains(o.available, field) {
+			printErr("Unknown JSON field: %q", field)
+			fmt.Fprintf(os.Stderr, "Available fields:\n")
+			for _, name := range o.sortedFields() {
+				fmt.Fprintf(os.Stderr, "  %s\n", name)
+			}
+			return fmt.Errorf("unknown JSON field: %s", field)
+		}
+	}
+	return nil
+}
+
+// write filters data (an object or a list of objects) down to the requested
+// fields and prints it as JSON, through --jq or through --template.
+func (o *jsonOutput) write(data interface{}) error {
+	var payload interface{}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		payload = o.filter(v)
+	case []map[string]interface{}:
+		list := make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			list = append(list, o.filter(item))
+		}
+		payload = list
+	default:
+		return fmt.Errorf("unsupported JSON payload %T", data)
+	}
+
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	t := term.FromEnv()
+	color := t.IsColorEnabled()
+
+	switch {
+	case o.jq != "":
+		err = jq.EvaluateFormatted(bytes.NewReader(buf), os.Stdout, o.jq, "  ", color)
+	case o.template != "":
+		width, _, sizeErr := t.Size()
+		if sizeErr != nil {
+			width = 80
+		}
+		tmpl := template.New(os.Stdout, width, color)
+		if err = tmpl.Parse(o.template); err == nil {
+			if err = tmpl.Execute(bytes.NewReader(buf)); err == nil {
+				err = tmpl.Flush()
+			}
+		}
+	default:
+		err = jsonpretty.Format(os.Stdout, bytes.NewReader(buf), "  ", color)
+	}
+
+	if err != nil {
+		printErr("%v", err)
+	}
+	return err
+}
+
+func (o *jsonOutput) filter(item map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(o.fields))
+	for _, field := range o.fields {
+		out[field] = item[field]
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
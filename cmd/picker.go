@@ -0,0 +1,155 @@
+// ABOUTME: Interactive prompts for gh-context: context picker and confirmations
+// ABOUTME: Filterable prompt on a terminal, numbered prompt as a fallback
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/prompter"
+	"github.com/cli/go-gh/v2/pkg/term"
+	"github.com/peterjmorgan/gh-context/internal/auth"
+	"github.com/peterjmorgan/gh-context/internal/config"
+)
+
+// errNoPrompt is returned when a context must be picked but nobody can be asked.
+var errNoPrompt = errors.New("no context given and not running interactively")
+
+// pickContext asks the user to choose a context. It uses go-gh's filterable
+// prompter when stdin and stdout are terminals, falls back to a numbered
+// prompt when only stdin is, and fails when there is no one to ask
+// (or GH_PROMPT_DISABLED is set, as for gh itself).
+func pickContext() (string, error) {
+	stdinTTY := term.IsTerminal(os.Stdin)
+	if !stdinTTY || os.Getenv("GH_PROMPT_DISABLED") != "" {
+		printErr("No context given and no terminal to prompt on")
+		printInfo("Usage: gh context use <name>")
+		return "", errNoPrompt
+	}
+
+	contexts, err := config.ListContexts()
+	if err != nil {
+		return "", err
+	}
+	if len(contexts) == 0 {
+		printInfo("No contexts found. Create one with: gh context new --from-current --name <name>")
+		return "", fmt.Errorf("no contexts")
+	}
+
+	active, _ := config.GetActive()
+	labels := pickerLabels(contexts, active)
+
+	defaultLabel := ""
+	for i, ctx := range contexts {
+		if ctx.Name == active {
+			defaultLabel = labels[i]
+		}
+	}
+
+	var choice int
+	if term.IsTerminal(os.Stdout) {
+		p := prompter.New(os.Stdin, os.Stdout, os.Stderr)
+		choice, err = p.Select("Switch to context", defaultLabel, labels)
+	} else {
+		choice, err = numberedPrompt(labels, contexts, active)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return contexts[choice].Name, nil
+}
+
+// pickerLabels renders one aligned line per context: name, user@host, key,
+// auth state and an active marker.
+func pickerLabels(contexts []*config.Context, active string) []string {
+	nameWidth, accountWidth := 0, 0
+	for _, ctx := range contexts {
+		nameWidth = max(nameWidth, len(ctx.Name))
+		accountWidth = max(accountWidth, len(ctx.User)+1+len(ctx.Hostname))
+	}
+
+	labels := make([]string, len(contexts))
+	for i, ctx := range contexts {
+		key := ctx.SSHKey
+		if key == "" {
+			key = ctx.Transport
+		}
+
+		authState := "✗ not logged in"
+		if auth.IsUserLoggedIn(ctx.Hostname, ctx.User) {
+			authState = "✓ logged in"
+		}
+
+		marker := ""
+		if ctx.Name == active {
+			marker = "  (active)"
+		}
+
+		labels[i] = fmt.Sprintf("%-*s  %-*s  %s  %s%s",
+			nameWidth, ctx.Name, accountWidth, ctx.User+"@"+ctx.Hostname, key, authState, marker)
+	}
+	return labels
+}
+
+// numberedPrompt lists the contexts on stderr and reads a number (or name)
+// from stdin. An empty answer keeps the active context.
+func numberedPrompt(labels []string, contexts []*config.Context, active string) (int, error) {
+	fmt.Fprintln(os.Stderr, "Switch to context:")
+	defaultChoice := -1
+	for i, label := range labels {
+		fmt.Fprintf(os.Stderr, "  %d) %s\n", i+1, label)
+		if contexts[i].Name == active {
+			defaultChoice = i
+		}
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		if defaultChoice >= 0 {
+			fmt.Fprintf(os.Stderr, "Enter a number [%d]: ", defaultChoice+1)
+		} else {
+			fmt.Fprint(os.Stderr, "Enter a number: ")
+		}
+
+		line, err := reader.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" && err != nil {
+			fmt.Fprintln(os.Stderr)
+			return 0, fmt.Errorf("no context selected")
+		}
+
+		if answer == "" && defaultChoice >= 0 {
+			return defaultChoice, nil
+		}
+		if n, convErr := strconv.Atoi(answer); convErr == nil && n >= 1 && n <= len(contexts) {
+			return n - 1, nil
+		}
+		for i, ctx := range contexts {
+			if ctx.Name == answer {
+				return i, nil
+			}
+		}
+
+		fmt.Fprintf(os.Stderr, "Please enter a number between 1 and %d\n", len(contexts))
+		if err != nil {
+			return 0, fmt.Errorf("no context selected")
+		}
+	}
+}
+
+// canConfirm reports whether a yes/no question can be asked on a terminal.
+func canConfirm() bool {
+	return term.IsTerminal(os.Stdin) && term.IsTerminal(os.Stdout) && os.Getenv("GH_PROMPT_DISABLED") == ""
+}
+
+// confirm asks a yes/no question with go-gh's prompter.
+func confirm(prompt string, defaultValue bool) (bool, error) {
+	p := prompter.New(os.Stdin, os.Stdout, os.Stderr)
+	return p.Confirm(prompt, defaultValue)
+}
@@ -0,0 +1,176 @@
+// ABOUTME: Prompt command for gh-context - prints the active context for shell prompts
+// ABOUTME: File reads only, plus snippets for starship, powerlevel10k and oh-my-posh
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Print the active context for a shell prompt",
+	Long: `Print the active context in a short form for shell prompts, like kube-ps1.
+
+Only local files are read: no git, gh or network calls. In a shell set up with
+'gh context env' (or an env-mode hook), the shell's own context is shown.
+Prints nothing if no context is active.
+
+Format placeholders:
+  {name}      active context name
+  {user}      GitHub user of the active context
+  {host}      GitHub hostname of the active context
+  {bound}     context bound to the current repo (.ghcontext or a rule)
+  {mismatch}  the --mismatch marker, if the repo is bound to another context
+
+Use --snippet to print a ready-made prompt segment for starship, p10k
+(powerlevel10k) or oh-my-posh.
+
+Examples:
+  gh context prompt
+  gh context prompt --format '{user}@{host}{mismatch}'
+  gh context prompt --snippet starship >> ~/.config/starship.toml
+  gh context prompt --snippet p10k >> ~/.p10k.zsh`,
+	Args: cobra.NoArgs,
+	RunE: runPrompt,
+}
+
+var (
+	promptFormat   string
+	promptMismatch string
+	promptSnippet  string
+)
+
+func init() {
+	promptCmd.Flags().StringVar(&promptFormat, "format", "{name}{mismatch}", "Output format (placeholders: {name}, {user}, {host}, {bound}, {mismatch})")
+	promptCmd.Flags().StringVar(&promptMismatch, "mismatch", " ≠ {bound}", "Marker for {mismatch} when the repo is bound to another context")
+	promptCmd.Flags().StringVar(&promptSnippet, "snippet", "", "Print a prompt segment for starship, p10k or oh-my-posh")
+	_ = promptCmd.RegisterFlagCompletionFunc("snippet", completeValues("starship", "p10k", "oh-my-posh"))
+}
+
+func runPrompt(cmd *cobra.Command, args []string) error {
+	if promptSnippet != "" {
+		return printPromptSnippet(promptSnippet)
+	}
+
+	// A per-shell context (gh context env) wins over the global one
+	name := os.Getenv("GH_CONTEXT")
+	if name == "" {
+		active, err := config.GetActive()
+		if err != nil {
+			return err
+		}
+		name = active
+	}
+	if name == "" {
+		return nil
+	}
+
+	bound := ""
+	if usesPlaceholder("{bound}", "{mismatch}") {
+		if cwd, err := os.Getwd(); err == nil {
+			bound, _ = promptContext(cwd)
+		}
+	}
+
+	user, host := "", ""
+	if usesPlaceholder("{user}", "{host}") {
+		if ctx, err := config.Load(name); err == nil {
+			user, host = ctx.User, ctx.Hostname
+		}
+	}
+
+	mismatch := ""
+	if bound != "" && bound != name {
+		mismatch = promptMismatch
+	}
+
+	// {mismatch} goes first so the marker can itself use the other placeholders
+	out := strings.ReplaceAll(promptFormat, "{mismatch}", mismatch)
+	out = strings.NewReplacer(
+		"{name}", name,
+		"{user}", user,
+		"{host}", host,
+		"{bound}", bound,
+	).Replace(out)
+
+	fmt.Println(out)
+	return nil
+}
+
+// usesPlaceholder reports whether the format (or the mismatch marker it may
+// expand to) mentions any of the placeholders, so unused data isn't read.
+func usesPlaceholder(placeholders ...string) bool {
+	for _, p := range placeholders {
+		if strings.Contains(promptFormat, p) {
+			return true
+		}
+		if strings.Contains(promptFormat, "{mismatch}") && strings.Contains(promptMismatch, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// printPromptSnippet prints a prompt segment that runs this binary directly,
+// since going through gh would cost more than the prompt itself.
+func printPromptSnippet(tool string) error {
+	command := shells["bash"].command() + " prompt"
+
+	switch tool {
+	case "starship":
+		fmt.Printf(`# gh-context: active context segment for starship
+# Add this to ~/.config/starship.toml
+
+[custom.gh_context]
+command = %s
+when = true
+shell = ["sh"]
+format = "[gh:$output]($style) "
+style = "bold purple"
+`, strconv.Quote(command))
+
+	case "p10k", "powerlevel10k":
+		fmt.Printf(`# gh-context: active context segment for powerlevel10k
+# Add this to ~/.p10k.zsh, then add gh_context to POWERLEVEL9K_LEFT_PROMPT_ELEMENTS
+# or POWERLEVEL9K_RIGHT_PROMPT_ELEMENTS
+
+function prompt_gh_context() {
+  local out
+  out="$(%s)" || return
+  [[ -n $out ]] && p10k segment -f 135 -i '' -t "${out//\%%/%%%%}"
+}
+`, command)
+
+	case "oh-my-posh":
+		segment, err := json.MarshalIndent(map[string]interface{}{
+			"type":       "command",
+			"style":      "plain",
+			"foreground": "#c678dd",
+			"template":   " gh:{{ .Output }} ",
+			"properties": map[string]string{
+				"shell":   "sh",
+				"command": command,
+			},
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf(`// gh-context: active context segment for oh-my-posh
+// Add this object to a block's "segments" list in your theme
+
+%s
+`, segment)
+
+	default:
+		return fmt.Errorf("unsupported prompt: %s (supported: starship, p10k, oh-my-posh)", tool)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// ABOUTME: Remotes command for gh-context - rewrites a repo's remote URLs for a context
+// ABOUTME: Matches remotes to the context's transport and host, with a diff preview first
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/diff"
+	"github.com/peterjmorgan/gh-context/internal/git"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+var remotesCmd = &cobra.Command{
+	Use:   "remotes [name]",
+	Short: "Rewrite the repo's remote URLs to match a context",
+	Long: `Rewrite the current repository's remote URLs (url and pushurl) so git uses
+the transport of a context:
+
+  ssh    git@<hostname>:owner/repo.git
+  alias  git@<hostname>-<name>:owner/repo.git (contexts using --ssh-strategy alias)
+  https  https://<hostname>/owner/repo.git
+
+Only remotes on the context's host are changed; an SSH alias counts as its
+HostName. The changes are shown as a diff and applied after confirmation.
+
+Without a name, the context bound to the repo is used (see 'gh context resolve'),
+falling back to the active context.
+
+Examples:
+  gh context remotes
+  gh context remotes work --dry-run
+  gh context remotes work --yes
+  gh context bind work --fix-remotes`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeContextNames(1),
+	RunE:              runRemotes,
+}
+
+var (
+	remotesDryRun bool
+	remotesYes    bool
+)
+
+func init() {
+	remotesCmd.Flags().BoolVar(&remotesDryRun, "dry-run", false, "Show the changes without applying them")
+	remotesCmd.Flags().BoolVarP(&remotesYes, "yes", "y", false, "Apply without asking")
+}
+
+// remoteChange is a remote URL setting to rewrite.
+type remoteChange struct {
+	Entry  git.RemoteURLEntry
+	NewURL string
+}
+
+func runRemotes(cmd *cobra.Command, args []string) error {
+	root, err := git.RepoRoot()
+	if err != nil {
+		return err
+	}
+	if root == "" {
+		printErr("Not inside a Git repository")
+		return fmt.Errorf("not in a git repository")
+	}
+
+	name := optionalArg(args)
+	if name == "" {
+		rc, err := resolveRepoContext(root)
+		if err != nil {
+			return err
+		}
+		if name = rc.Name; name == "" {
+			if name, err = config.GetActive(); err != nil {
+				return err
+			}
+		}
+		if name == "" {
+			printErr("Repo is not bound and no context is active")
+			printInfo("Pass a context name: gh context remotes <name>")
+			return fmt.Errorf("no context")
+		}
+	}
+
+	ctx, err := config.Load(name)
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	return fixRemotes(root, ctx, remotesDryRun, remotesYes)
+}
+
+// fixRemotes previews and applies the remote rewrites for ctx in the repo at
+// root. Shared by remotes and bind --fix-remotes.
+func fixRemotes(root string, ctx *config.Context, dryRun, yes bool) error {
+	changes, err := planRemoteChanges(root, ctx)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		printOk("Remotes already match context '%s' (%s)", ctx.Name, ctx.Transport)
+		return nil
+	}
+
+	fmt.Print(remoteChangesDiff(changes))
+
+	if dryRun {
+		return nil
+	}
+	if !yes {
+		if !canConfirm() {
+			printErr("Refusing to rewrite remotes without confirmation; rerun with --yes")
+			return fmt.Errorf("confirmation required")
+		}
+		answer, err := confirm(fmt.Sprintf("Rewrite %d remote URL(s) for '%s'?", len(changes), ctx.Name), true)
+		if err != nil {
+			return err
+		}
+		if !answer {
+			printInfo("Remotes unchanged")
+			return nil
+		}
+	}
+
+	for _, c := range changes {
+		if err := git.SetRemoteURLAt(root, c.Entry, c.NewURL); err != nil {
+			printErr("Failed to rewrite %s: %v", c.Entry.ConfigKey(), err)
+			return err
+		}
+	}
+	printOk("Rewrote %d remote URL(s) for context '%s'", len(changes), ctx.Name)
+	return nil
+}
+
+// planRemoteChanges returns the remote URLs of the repo at root that don't
+// match the transport of ctx. Remotes on other hosts, and URLs that aren't
+// host/owner/repo (local paths, for one), are left out.
+func planRemoteChanges(root string, ctx *config.Context) ([]remoteChange, error) {
+	entries, err := git.RemoteURLsAt(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []remoteChange
+	for _, e := range entries {
+		remote, err := git.ParseRemoteURL(e.URL)
+		if err != nil {
+			continue
+		}
+		if !strings.EqualFold(remote.Host, ctx.Hostname) && !strings.EqualFold(realSSHHost(remote.Host), ctx.Hostname) {
+			continue
+		}
+
+		newURL := contextRemoteURL(ctx, remote)
+		if !strings.HasSuffix(e.URL, ".git") {
+			newURL = strings.TrimSuffix(newURL, ".git") // Keep the user's form
+		}
+		if newURL != e.URL {
+			changes = append(changes, remoteChange{Entry: e, NewURL: newURL})
+		}
+	}
+	return changes, nil
+}
+
+// contextRemoteURL returns the URL git should use for remote under ctx.
+func contextRemoteURL(ctx *config.Context, remote *git.Remote) string {
+	switch {
+	case ctx.Transport == "https":
+		return remote.HTTPSURL(ctx.Hostname)
+	case ctx.UsesSSHAlias():
+		return remote.SSHURL(ssh.AliasName(ctx.Hostname, ctx.Name))
+	default:
+		return remote.SSHURL(ctx.Hostname)
+	}
+}
+
+// remoteChangesDiff renders the changes as a diff of the affected settings.
+func remoteChangesDiff(changes []remoteChange) string {
+	var before, after []string
+	for _, c := range changes {
+		before = append(before, c.Entry.ConfigKey()+" = "+c.Entry.URL)
+		after = append(after, c.Entry.ConfigKey()+" = "+c.NewURL)
+	}
+	return diff.Unified("remotes (current)", "remotes (rewritten)", before, after, 0)
+}
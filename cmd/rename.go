@@ -0,0 +1,176 @@
+// ABOUTME: Rename command for gh-context - renames a saved context
+// ABOUTME: Carries the active pointer, SSH alias and .ghcontext bindings over to the new name
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/git"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+var renameCmd = &cobra.Command{
+	Use:     "rename <old> <new>",
+	Aliases: []string{"mv"},
+	Short:   "Rename a context",
+	Long: `Rename a saved context. The active and previous context pointers follow
+the rename, and a managed SSH alias is renamed with it. Auto-binding rules
+that name the old context (see 'gh context resolve --help') are pointed at the
+new name.
+
+.ghcontext files that name the old context are found in the registered
+repositories (see 'gh context bindings'), the current repository and
+every --repo given. You are asked before they are
+rewritten (use --yes to skip the question).
+
+Examples:
+  gh context rename work acme
+  gh context rename work acme --repo ~/src/acme-api --repo ~/src/acme-web --yes`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeContextNames(1),
+	RunE:              runRename,
+}
+
+var (
+	renameRepos []string
+	renameYes   bool
+)
+
+func init() {
+	renameCmd.Flags().StringArrayVar(&renameRepos, "repo", nil, "Also rewrite .ghcontext in this repository (repeatable)")
+	renameCmd.Flags().BoolVarP(&renameYes, "yes", "y", false, "Rewrite .ghcontext files without asking")
+}
+
+func runRename(cmd *cobra.Command, args []string) error {
+	oldName, newName := args[0], args[1]
+
+	active, _ := config.GetActive()
+	candidates := bindingCandidates(oldName, renameRepos)
+
+	ctx, err := config.Rename(oldName, newName)
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+	printOk("Renamed context '%s' to '%s'", oldName, newName)
+	if active == oldName {
+		printInfo("Active context is now '%s'", newName)
+	}
+
+	if ctx.UsesSSHAlias() {
+		renameSSHAlias(ctx, oldName, active == oldName)
+	}
+
+	if n, err := config.RenameRuleContext(oldName, newName); err != nil {
+		printErr("Rules naming '%s' were not updated: %v", oldName, err)
+		printInfo("Edit them to name '%s' instead", newName)
+	} else if n > 0 {
+		printOk("Pointed %d auto-binding rule(s) at '%s'", n, newName)
+	}
+
+	return rewriteBindings(oldName, newName, candidates)
+}
+
+// renameSSHAlias replaces the managed alias of a renamed context. If the
+// context is active, git URL rewrites are pointed at the new alias too.
+func renameSSHAlias(ctx *config.Context, oldName string, isActive bool) {
+	old := *ctx
+	old.Name = oldName
+	if _, err := removeSSHAlias(&old); err != nil {
+		printErr("Failed to remove SSH alias 'Host %s': %v", ssh.AliasName(ctx.Hostname, oldName), err)
+	}
+
+	if isActive {
+		activateSSHAlias(ctx)
+		return
+	}
+	if err := ensureSSHAlias(ctx); err != nil {
+		printErr("Failed to add SSH alias: %v", err)
+		return
+	}
+	printOk("Renamed SSH alias to 'Host %s'", ssh.AliasName(ctx.Hostname, ctx.Name))
+}
+
+// bindingCandidates returns the roots of the registered repositories bound to
+// name, the current repository and each of dirs, without duplicates.
+// Directories that aren't repositories are reported.
+func bindingCandidates(name string, dirs []string) []string {
+	var roots []string
+	seen := make(map[string]bool)
+	add := func(root string) {
+		if root != "" && !seen[root] {
+			seen[root] = true
+			roots = append(roots, root)
+		}
+	}
+
+	if registered, err := config.BindingsFor(name); err == nil {
+		for _, b := range registered {
+			add(b.Repo)
+		}
+	}
+	if root, err := git.RepoRoot(); err == nil {
+		add(root)
+	}
+	for _, dir := range dirs {
+		root, err := git.RepoRootAt(ssh.ExpandPath(dir))
+		if err != nil || root == "" {
+			printErr("Skipping %s: not a Git repository", dir)
+			continue
+		}
+		add(root)
+	}
+	return roots
+}
+
+// rewriteBindings points the .ghcontext files among roots that name oldName
+// at newName, after asking (unless --yes was given).
+func rewriteBindings(oldName, newName string, roots []string) error {
+	var bound []string
+	for _, root := range roots {
+		if binding, err := git.ReadBindingAt(root); err == nil && binding == oldName {
+			bound = append(bound, root)
+		}
+	}
+	if len(bound) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	printPlain("These repositories are bound to '%s':", oldName)
+	for _, root := range bound {
+		printPlain("  %s", root)
+	}
+
+	rewrite := renameYes
+	if !rewrite {
+		if !canConfirm() {
+			printInfo("Not rewriting them without a terminal to ask on; run 'gh context bind %s' in each", newName)
+			return nil
+		}
+		answer, err := confirm(fmt.Sprintf("Rewrite their .ghcontext to '%s'?", newName), true)
+		if err != nil {
+			return err
+		}
+		rewrite = answer
+	}
+	if !rewrite {
+		printInfo("Left .ghcontext files unchanged")
+		return nil
+	}
+
+	for _, root := range bound {
+		if err := git.WriteBindingAt(root, newName); err != nil {
+			printErr("Failed to rewrite %s: %v", root, err)
+			continue
+		}
+		if err := config.RegisterBinding(root, newName); err != nil {
+			printErr("Failed to record binding: %v", err)
+		}
+		printOk("Rebound %s to '%s'", root, newName)
+	}
+	return nil
+}
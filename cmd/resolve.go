@@ -0,0 +1,121 @@
+// ABOUTME: Resolve command for gh-context - prints the context a repo should use
+// ABOUTME: Checks .ghcontext first, then the auto-binding rules against origin and the repo path
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/git"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Print the context bound to the current repository",
+	Long: `Print the name of the context for the current repository: the one named in
+.ghcontext, or else the first matching rule in the rules file (rules.yml in the
+contexts directory). Prints nothing and exits 1 if no context applies.
+
+Rules match the origin remote (host/owner/repo) or the repository path:
+
+  version: 1
+  rules:
+    - remote: github.com/acme-corp/*
+      context: work
+    - remote: ghe.acme.com/**
+      context: work
+    - dir: ~/src/personal/**
+      context: personal
+
+* matches within one path segment and ** matches any number of segments.
+Remotes using an SSH alias (e.g. github.com-work) match on the real hostname.
+
+Used by the shell hooks; 'gh context current' shows which rule matched.`,
+	Args: cobra.NoArgs,
+	RunE: runResolve,
+}
+
+// repoContext records how the context of a repository was chosen.
+type repoContext struct {
+	Root        string
+	Name        string       // Empty if nothing applies
+	BindingPath string       // Set if .ghcontext named the context
+	Rule        *config.Rule // Set if a rule matched
+	Remote      string       // host/owner/repo of origin, if it could be parsed
+}
+
+func runResolve(cmd *cobra.Command, args []string) error {
+	root, err := git.RepoRoot()
+	if err != nil {
+		return err
+	}
+	if root == "" {
+		return &exitCodeError{code: 1}
+	}
+
+	rc, err := resolveRepoContext(root)
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+	if rc.Name == "" {
+		return &exitCodeError{code: 1}
+	}
+
+	fmt.Println(rc.Name)
+	return nil
+}
+
+// resolveRepoContext finds the context for the repo at root. .ghcontext wins;
+// the rules are only consulted when the repo has no binding.
+func resolveRepoContext(root string) (*repoContext, error) {
+	return resolveRepoContextWith(root, git.OriginAt)
+}
+
+// resolveRepoContextWith is resolveRepoContext with the origin lookup
+// swapped, so the prompt can avoid running git.
+func resolveRepoContextWith(root string, originAt func(root string) (*git.Remote, error)) (*repoContext, error) {
+	rc := &repoContext{Root: root}
+
+	binding, err := git.ReadBindingAt(root)
+	if err != nil {
+		return nil, err
+	}
+	if binding != "" {
+		rc.Name = binding
+		rc.BindingPath = git.BindingPathAt(root)
+		return rc, nil
+	}
+
+	rules, err := config.LoadRules()
+	if err != nil || len(rules) == 0 {
+		return rc, err
+	}
+
+	if origin, err := originAt(root); err == nil && origin != nil {
+		origin.Host = realSSHHost(origin.Host)
+		rc.Remote = origin.String()
+	}
+
+	if rule := config.MatchRule(rules, rc.Remote, root); rule != nil {
+		rc.Rule = rule
+		rc.Name = rule.Context
+	}
+	return rc, nil
+}
+
+// realSSHHost maps an SSH Host alias (such as a context's github.com-work) to
+// the hostname it connects to. Other hosts are returned unchanged.
+func realSSHHost(host string) string {
+	sshCfg, err := ssh.ParseConfig("")
+	if err != nil {
+		return host
+	}
+	if hostname := sshCfg.Get(host, "hostname"); hostname != "" {
+		return hostname
+	}
+	return host
+}
@@ -21,6 +21,25 @@ without manually managing authentication each time.
 Contexts are stored in: ~/.config/gh/contexts/ (or %APPDATA%\gh\contexts on Windows)`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	Args:          rootArgs,
+	RunE:          runRoot,
+}
+
+// rootArgs accepts no arguments, or "-" as a shortcut for "use -".
+func rootArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 || (len(args) == 1 && args[0] == "-") {
+		return nil
+	}
+	printErr("Unknown command %q", args[0])
+	printInfo("Run 'gh context --help' for usage")
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func runRoot(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		return runUse(cmd, args) // "gh context -" switches back like kubectx
+	}
+	return cmd.Help()
 }
 
 // Execute runs the root command.
@@ -29,6 +48,9 @@ func Execute() error {
 }
 
 func init() {
+	// gh context completion replaces cobra's, which would complete "gh-context"
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
 	// Add all subcommands
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(currentCmd)
@@ -37,9 +59,25 @@ func init() {
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(bindCmd)
 	rootCmd.AddCommand(unbindCmd)
+	rootCmd.AddCommand(remotesCmd)
 	rootCmd.AddCommand(applyCmd)
 	rootCmd.AddCommand(shellHookCmd)
 	rootCmd.AddCommand(authStatusCmd)
+	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(sshCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(renameCmd)
+	rootCmd.AddCommand(copyCmd)
+	rootCmd.AddCommand(editCmd)
+	rootCmd.AddCommand(bindingsCmd)
+	rootCmd.AddCommand(resolveCmd)
+	rootCmd.AddCommand(hookEvalCmd)
+	rootCmd.AddCommand(promptCmd)
+	rootCmd.AddCommand(completionCmd)
 }
 
 // Output helpers that match the bash script style
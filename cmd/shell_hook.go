@@ -1,10 +1,14 @@
 // ABOUTME: Shell-hook command for gh-context - generates shell integration code
-// ABOUTME: Supports bash, zsh, PowerShell, and fish shells for auto-apply on cd
+// ABOUTME: One table describes each shell's hook and statement syntax for bash, zsh, PowerShell and fish
 
 package cmd
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
 
 	"github.com/spf13/cobra"
 )
@@ -12,7 +16,8 @@ import (
 var shellHookCmd = &cobra.Command{
 	Use:   "shell-hook [shell]",
 	Short: "Print shell snippet for auto-apply on cd",
-	Long: `Print shell integration code that automatically applies context when entering a repo with .ghcontext.
+	Long: `Print shell integration code that automatically applies context when entering a repo with .ghcontext,
+or one matched by an auto-binding rule (see 'gh context resolve --help').
 
 Supported shells: bash, zsh, powershell, pwsh, fish
 
@@ -21,6 +26,16 @@ Examples:
   gh context shell-hook zsh >> ~/.zshrc
   gh context shell-hook powershell >> $PROFILE
   gh context shell-hook fish >> ~/.config/fish/config.fish
+  gh context shell-hook zsh --mode env >> ~/.zshrc
+
+Modes:
+  use  Run 'gh context use' when entering a bound repo (switches globally)
+  env  Export the context's environment into this shell only (see 'gh context env'),
+       and clear it again when leaving the repo. Other open shells are unaffected.
+
+The hook runs this binary's 'hook-eval' before each prompt. It finds the repo
+and its binding without starting git or gh, and prints shell statements only when
+something needs to change. Regenerate the hook if the binary moves.
 
 If no shell is specified, outputs bash/zsh compatible code.`,
 	Args:      cobra.MaximumNArgs(1),
@@ -28,152 +43,146 @@ If no shell is specified, outputs bash/zsh compatible code.`,
 	RunE:      runShellHook,
 }
 
-func runShellHook(cmd *cobra.Command, args []string) error {
-	shell := "bash" // Default
-	if len(args) > 0 {
-		shell = args[0]
-	}
-
-	var hook string
-	switch shell {
-	case "bash":
-		hook = bashHook()
-	case "zsh":
-		hook = zshHook()
-	case "powershell", "pwsh":
-		hook = powershellHook()
-	case "fish":
-		hook = fishHook()
-	default:
-		return fmt.Errorf("unsupported shell: %s (supported: bash, zsh, powershell, pwsh, fish)", shell)
-	}
+var shellHookMode string
 
-	fmt.Print(hook)
-	return nil
+func init() {
+	shellHookCmd.Flags().StringVar(&shellHookMode, "mode", "use", "How the hook applies contexts (use or env)")
+	_ = shellHookCmd.RegisterFlagCompletionFunc("mode", completeValues("use", "env"))
+	shells["pwsh"] = shells["powershell"]
 }
 
-func bashHook() string {
-	return `# gh-context: Auto-apply context when entering a repo with .ghcontext
-# Add this to your ~/.bashrc
-
-__gh_context_auto_apply() {
-  local root
-  root="$(git rev-parse --show-toplevel 2>/dev/null)" || return 0
-
-  if [[ -f "$root/.ghcontext" ]]; then
-    local name current
-    name="$(cat "$root/.ghcontext")"
-    current=""
-    [[ -f "${XDG_CONFIG_HOME:-$HOME/.config}/gh/contexts/active" ]] && \
-      current="$(cat "${XDG_CONFIG_HOME:-$HOME/.config}/gh/contexts/active")"
-
-    if [[ "$current" != "$name" ]]; then
-      echo "• Auto-applying gh context: $name"
-      gh context use "$name" 2>/dev/null || true
-    fi
-  fi
+// shellSyntax is everything gh-context knows about one shell: how to quote,
+// export and unset for 'env' and 'hook-eval', and the hook that runs
+// 'hook-eval' before each prompt.
+type shellSyntax struct {
+	rcFile string
+	quote  func(string) string
+	export string // Format for an assignment: name, quoted value
+	unset  string // Format for a removal: name
+	invoke string // Format for running a quoted executable path
+	hook   string // Template for the prompt hook
 }
 
-PROMPT_COMMAND="__gh_context_auto_apply${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
-`
+// shells is the single source of truth for shell integration. pwsh is an
+// alias for powershell.
+var shells = map[string]*shellSyntax{
+	"bash": {
+		rcFile: "~/.bashrc",
+		quote:  shellQuote,
+		export: "export %s=%s",
+		unset:  "unset %s",
+		invoke: "%s",
+		hook: `__gh_context_hook() {
+  eval "$({{.Command}} hook-eval --shell bash --mode {{.Mode}} --pwd "$PWD")"
 }
 
-func zshHook() string {
-	return `# gh-context: Auto-apply context when entering a repo with .ghcontext
-# Add this to your ~/.zshrc
-
-__gh_context_auto_apply() {
-  local root
-  root="$(git rev-parse --show-toplevel 2>/dev/null)" || return 0
-
-  if [[ -f "$root/.ghcontext" ]]; then
-    local name current
-    name="$(cat "$root/.ghcontext")"
-    current=""
-    [[ -f "${XDG_CONFIG_HOME:-$HOME/.config}/gh/contexts/active" ]] && \
-      current="$(cat "${XDG_CONFIG_HOME:-$HOME/.config}/gh/contexts/active")"
-
-    if [[ "$current" != "$name" ]]; then
-      echo "• Auto-applying gh context: $name"
-      gh context use "$name" 2>/dev/null || true
-    fi
-  fi
+if [[ ";${PROMPT_COMMAND:-};" != *";__gh_context_hook;"* ]]; then
+  PROMPT_COMMAND="__gh_context_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`,
+	},
+	"zsh": {
+		rcFile: "~/.zshrc",
+		quote:  shellQuote,
+		export: "export %s=%s",
+		unset:  "unset %s",
+		invoke: "%s",
+		hook: `__gh_context_hook() {
+  eval "$({{.Command}} hook-eval --shell zsh --mode {{.Mode}} --pwd "$PWD")"
 }
 
 autoload -U add-zsh-hook
-add-zsh-hook precmd __gh_context_auto_apply
-`
-}
-
-func powershellHook() string {
-	return `# gh-context: Auto-apply context when entering a repo with .ghcontext
-# Add this to your PowerShell profile ($PROFILE)
-
-function Invoke-GhContextAutoApply {
-    $root = git rev-parse --show-toplevel 2>$null
-    if (-not $root) { return }
-
-    $ghContextFile = Join-Path $root ".ghcontext"
-    if (Test-Path $ghContextFile) {
-        $name = (Get-Content $ghContextFile -Raw).Trim()
-
-        # Get current active context
-        $configDir = if ($env:XDG_CONFIG_HOME) { $env:XDG_CONFIG_HOME } else { "$env:APPDATA" }
-        $activeFile = Join-Path $configDir "gh\contexts\active"
-        $current = ""
-        if (Test-Path $activeFile) {
-            $current = (Get-Content $activeFile -Raw).Trim()
-        }
-
-        if ($current -ne $name) {
-            Write-Host "• Auto-applying gh context: $name"
-            gh context use $name 2>$null
-        }
-    }
+add-zsh-hook precmd __gh_context_hook
+`,
+	},
+	"fish": {
+		rcFile: "~/.config/fish/config.fish",
+		quote:  fishQuote,
+		export: "set -gx %s %s",
+		unset:  "set -e %s",
+		invoke: "%s",
+		hook: `function __gh_context_hook --on-event fish_prompt
+    {{.Command}} hook-eval --shell fish --mode {{.Mode}} --pwd "$PWD" | source
+end
+`,
+	},
+	"powershell": {
+		rcFile: "PowerShell profile ($PROFILE)",
+		quote:  powershellQuote,
+		export: "$env:%s = %s",
+		unset:  "Remove-Item Env:%s -ErrorAction SilentlyContinue",
+		invoke: "& %s",
+		hook: `function Invoke-GhContextHook {
+    $statements = {{.Command}} hook-eval --shell powershell --mode {{.Mode}} --pwd "$PWD" | Out-String
+    if ($statements) { Invoke-Expression $statements }
 }
 
 # Hook into prompt
 $__ghContextOriginalPrompt = $function:prompt
 function prompt {
-    Invoke-GhContextAutoApply
+    Invoke-GhContextHook
     & $__ghContextOriginalPrompt
 }
-`
+`,
+	},
 }
 
-func fishHook() string {
-	return `# gh-context: Auto-apply context when entering a repo with .ghcontext
-# Add this to your ~/.config/fish/config.fish
-
-function __gh_context_auto_apply --on-variable PWD
-    set -l root (git rev-parse --show-toplevel 2>/dev/null)
-    if test -z "$root"
-        return
-    end
-
-    set -l ghcontext_file "$root/.ghcontext"
-    if test -f $ghcontext_file
-        set -l name (cat $ghcontext_file | string trim)
-
-        # Get current active context
-        set -l config_dir
-        if test -n "$XDG_CONFIG_HOME"
-            set config_dir $XDG_CONFIG_HOME
-        else
-            set config_dir ~/.config
-        end
-
-        set -l active_file "$config_dir/gh/contexts/active"
-        set -l current ""
-        if test -f $active_file
-            set current (cat $active_file | string trim)
-        end
-
-        if test "$current" != "$name"
-            echo "• Auto-applying gh context: $name"
-            gh context use $name 2>/dev/null
-        end
-    end
-end
-`
+// lookupShell returns the syntax of a supported shell.
+func lookupShell(name string) (*shellSyntax, error) {
+	sh, ok := shells[name]
+	if !ok {
+		names := make([]string, 0, len(shells))
+		for n := range shells {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unsupported shell: %s (supported: %s)", name, strings.Join(names, ", "))
+	}
+	return sh, nil
+}
+
+// command renders a shell statement that runs this binary.
+func (sh *shellSyntax) command() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "gh context" // Slower, but works wherever gh is on PATH
+	}
+	return fmt.Sprintf(sh.invoke, sh.quote(exe))
+}
+
+// checkHookMode validates a --mode value shared by shell-hook and hook-eval.
+func checkHookMode(mode string) error {
+	switch mode {
+	case "use", "env":
+		return nil
+	default:
+		return fmt.Errorf("hook mode must be 'use' or 'env', got: %s", mode)
+	}
+}
+
+func runShellHook(cmd *cobra.Command, args []string) error {
+	shell := "bash" // Default
+	if len(args) > 0 {
+		shell = args[0]
+	}
+
+	if err := checkHookMode(shellHookMode); err != nil {
+		return err
+	}
+	sh, err := lookupShell(shell)
+	if err != nil {
+		return err
+	}
+
+	summary := "Auto-apply the bound context when entering a repo with .ghcontext or a matching rule"
+	if shellHookMode == "env" {
+		summary = "Export a repo's bound context into this shell only"
+	}
+	fmt.Printf("# gh-context: %s\n# Add this to your %s\n\n", summary, sh.rcFile)
+
+	tmpl, err := template.New(shell).Parse(sh.hook)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(os.Stdout, struct{ Command, Mode string }{sh.command(), shellHookMode})
 }
@@ -0,0 +1,121 @@
+// ABOUTME: SSH command group for gh-context - manages ~/.ssh/config backups
+// ABOUTME: Lists, diffs and restores the backups taken before each SSH config change
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/peterjmorgan/gh-context/internal/diff"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+var sshCmd = &cobra.Command{
+	Use:   "ssh",
+	Short: "Inspect and restore ~/.ssh/config backups",
+	Long: `gh-context backs up ~/.ssh/config before every change it makes.
+Backups are kept in ~/.ssh/gh-context-backups/; the newest 10 are retained
+(set GH_CONTEXT_SSH_BACKUPS to change this, or 0 to disable backups).`,
+}
+
+var sshBackupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "List SSH config backups, newest first",
+	Args:  cobra.NoArgs,
+	RunE:  runSSHBackups,
+}
+
+var sshDiffCmd = &cobra.Command{
+	Use:   "diff [id]",
+	Short: "Show a unified diff from a backup to the current SSH config",
+	Long:  `Show what changed between a backup (default: the most recent) and the current ~/.ssh/config.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runSSHDiff,
+}
+
+var sshRestoreCmd = &cobra.Command{
+	Use:   "restore [id]",
+	Short: "Restore ~/.ssh/config from a backup",
+	Long: `Replace ~/.ssh/config with a backup (default: the most recent).
+The current config is backed up first, so a restore can itself be undone.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSSHRestore,
+}
+
+func init() {
+	sshCmd.AddCommand(sshBackupsCmd)
+	sshCmd.AddCommand(sshDiffCmd)
+	sshCmd.AddCommand(sshRestoreCmd)
+}
+
+func runSSHBackups(cmd *cobra.Command, args []string) error {
+	configPath := ssh.DefaultConfigPath()
+	backups, err := ssh.ListBackups(configPath)
+	if err != nil {
+		return err
+	}
+
+	if len(backups) == 0 {
+		printInfo("No backups of %s yet", configPath)
+		return nil
+	}
+
+	printPlain("Backups of %s (newest first):", configPath)
+	for _, b := range backups {
+		fmt.Printf("  %s\t%s\t%d bytes\n", b.ID, b.Time.Format("2006-01-02 15:04:05"), b.Size)
+	}
+	return nil
+}
+
+func runSSHDiff(cmd *cobra.Command, args []string) error {
+	configPath := ssh.DefaultConfigPath()
+	backup, err := ssh.FindBackup(configPath, optionalArg(args))
+	if err != nil {
+		return err
+	}
+
+	old, err := backup.ReadLines()
+	if err != nil {
+		return err
+	}
+	current, err := ssh.ParseConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	out := diff.Unified("backup "+backup.ID, configPath, old, current.Lines, 3)
+	if out == "" {
+		printInfo("No differences between backup %s and %s", backup.ID, configPath)
+		return nil
+	}
+	fmt.Print(out)
+	return nil
+}
+
+func runSSHRestore(cmd *cobra.Command, args []string) error {
+	configPath := ssh.DefaultConfigPath()
+	backup, err := ssh.FindBackup(configPath, optionalArg(args))
+	if err != nil {
+		return err
+	}
+
+	previous, err := ssh.RestoreBackup(configPath, backup)
+	if err != nil {
+		return err
+	}
+
+	printOk("Restored %s from backup %s", configPath, backup.ID)
+	if previous != nil {
+		printInfo("Previous config saved as backup %s", previous.ID)
+	}
+	return nil
+}
+
+// optionalArg returns the first argument or "".
+func optionalArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return ""
+}
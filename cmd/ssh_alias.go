@@ -0,0 +1,95 @@
+// ABOUTME: SSH alias helpers shared by new, use and delete commands
+// ABOUTME: Keeps managed Host aliases and git URL rewrites in sync with contexts
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/git"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+)
+
+// ensureSSHAlias adds or refreshes the managed Host alias for a context.
+func ensureSSHAlias(ctx *config.Context) error {
+	return ssh.Update("", func(sshCfg *ssh.ConfigFile) (bool, error) {
+		return sshCfg.SetAlias(ssh.AliasName(ctx.Hostname, ctx.Name), ctx.Hostname, ctx.SSHKey)
+	})
+}
+
+// removeSSHAlias deletes the managed Host alias and URL rewrites of a context.
+// Returns true if an alias was removed.
+func removeSSHAlias(ctx *config.Context) (bool, error) {
+	alias := ssh.AliasName(ctx.Hostname, ctx.Name)
+
+	if err := git.RemoveSSHAliasRewrite(git.ScopeGlobal, alias); err != nil {
+		return false, err
+	}
+
+	removed := false
+	err := ssh.Update("", func(sshCfg *ssh.ConfigFile) (bool, error) {
+		var err error
+		removed, err = sshCfg.RemoveAlias(alias)
+		return removed, err
+	})
+	return removed, err
+}
+
+// switchSSHAliasRewrites points git at the context's alias, clearing the rewrites
+// of every other managed alias for the same host. Rewrites are always global,
+// like the ~/.ssh/config edits they replace.
+func switchSSHAliasRewrites(sshCfg *ssh.ConfigFile, ctx *config.Context) error {
+	want := ""
+	if ctx.UsesSSHAlias() && ctx.Transport == "ssh" {
+		want = ssh.AliasName(ctx.Hostname, ctx.Name)
+	}
+
+	for _, a := range sshCfg.ManagedAliases() {
+		if a.Hostname != ctx.Hostname || a.Alias == want {
+			continue
+		}
+		if err := git.RemoveSSHAliasRewrite(git.ScopeGlobal, a.Alias); err != nil {
+			return err
+		}
+	}
+
+	if want == "" {
+		return nil
+	}
+	return git.SetSSHAliasRewrite(git.ScopeGlobal, ctx.Hostname, want)
+}
+
+// activateSSHAlias updates managed alias state when switching to ctx.
+// Failures are reported but don't abort the switch.
+func activateSSHAlias(ctx *config.Context) {
+	alias := ssh.AliasName(ctx.Hostname, ctx.Name)
+	useAlias := ctx.UsesSSHAlias() && ctx.Transport == "ssh" && ctx.SSHKey != ""
+
+	if useAlias {
+		printInfo("Activating SSH alias: Host %s (%s)", alias, ctx.SSHKey)
+	}
+
+	err := ssh.Update("", func(sshCfg *ssh.ConfigFile) (bool, error) {
+		// Recreate the alias if it was removed or the key changed
+		changed := false
+		if useAlias {
+			var err error
+			if changed, err = sshCfg.SetAlias(alias, ctx.Hostname, ctx.SSHKey); err != nil {
+				return false, err
+			}
+		}
+		if err := switchSSHAliasRewrites(sshCfg, ctx); err != nil {
+			return changed, fmt.Errorf("failed to update git URL rewrites: %w", err)
+		}
+		return changed, nil
+	})
+	if err != nil {
+		printErr("Failed to activate SSH alias: %v", err)
+		return
+	}
+
+	if useAlias {
+		printOk("git@%s URLs now use Host %s", ctx.Hostname, alias)
+	}
+}
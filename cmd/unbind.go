@@ -4,6 +4,7 @@
 package cmd
 
 import (
+	"github.com/peterjmorgan/gh-context/internal/config"
 	"github.com/peterjmorgan/gh-context/internal/git"
 	"github.com/spf13/cobra"
 )
@@ -41,6 +42,9 @@ func runUnbind(cmd *cobra.Command, args []string) error {
 	if removeErr := git.RemoveBinding(); removeErr != nil {
 		return removeErr
 	}
+	if err := config.UnregisterBinding(root); err != nil {
+		printErr("Failed to update binding registry: %v", err)
+	}
 
 	printOk("Removed repo binding")
 	return nil
@@ -5,32 +5,154 @@ package cmd
 
 import (
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/peterjmorgan/gh-context/internal/auth"
 	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/git"
 	"github.com/peterjmorgan/gh-context/internal/ssh"
 	"github.com/spf13/cobra"
 )
 
 var useCmd = &cobra.Command{
-	Use:   "use <name>",
-	Short: "Switch to context (updates SSH config and gh auth)",
+	Use:     "use [name]",
+	Aliases: []string{"switch"},
+	Short:   "Switch to context (updates SSH config and gh auth)",
 	Long: `Switch to a saved context. This will:
 1. Set the active context
-2. Update ~/.ssh/config to use the correct SSH key
+2. Update ~/.ssh/config to use the correct SSH key (or, for contexts using the
+   alias strategy, rewrite git URLs to the context's managed Host alias)
 3. Switch gh CLI authentication to the correct user
+4. Write the context's git identity (user.name, user.email, signing key)
+   and revert the identity of the previously active context
+5. For https contexts, make git push over HTTPS as the context's user: gh
+   becomes the credential helper for the host (like 'gh auth setup-git') and
+   credential.https://<host>.username names the account, so a keychain or
+   credential store can't answer for another account
 
-If authentication is not configured, provides instructions to set it up.`,
-	Args: cobra.ExactArgs(1),
-	RunE: runUse,
+With --agent, the context's SSH key is also loaded into the ssh-agent at
+$SSH_AUTH_SOCK and the keys of other contexts are unloaded, so the agent can't
+offer another account's key first. --agent-lifetime limits how long the key
+stays loaded.
+
+Switching to an ssh context also checks that its SSH key authenticates as the
+context's user (see 'gh context verify'), and warns loudly if it doesn't.
+--no-verify skips this network check; the shell hook's automatic switches do.
+
+If authentication is not configured, provides instructions to set it up.
+
+Without a name, opens an interactive picker (type to filter).
+Use "-" as the name to switch back to the previous context.
+
+Examples:
+  gh context use work
+  gh context use work --agent --agent-lifetime 8h
+  gh context use -`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeContextNames(1),
+	RunE:              runUse,
+}
+
+var (
+	useGitScope      string
+	useAgent         bool
+	useAgentLifetime time.Duration
+	useNoVerify      bool
+)
+
+// switchOptions controls the optional steps of switchContext.
+type switchOptions struct {
+	Scope         git.Scope     // Git config to write the context identity to
+	Agent         bool          // Load the context's key into ssh-agent, unload the others
+	AgentLifetime time.Duration // How long the agent keeps the key; 0 for no limit
+	Verify        bool          // Check which account the SSH key logs in as
+	KeepIdentity  bool          // Leave git identity config alone
+}
+
+func init() {
+	useCmd.Flags().StringVar(&useGitScope, "git-scope", "global", "Git config to write the context identity to (global or local)")
+	useCmd.Flags().BoolVar(&useAgent, "agent", false, "Load the context's SSH key into ssh-agent and unload other contexts' keys")
+	useCmd.Flags().DurationVar(&useAgentLifetime, "agent-lifetime", 0, "How long ssh-agent keeps the key, e.g. 8h (implies --agent; default: no limit)")
+	useCmd.Flags().BoolVar(&useNoVerify, "no-verify", false, "Don't check which account the SSH key logs in as")
+	_ = useCmd.RegisterFlagCompletionFunc("git-scope", completeValues("global", "local"))
 }
 
 func runUse(cmd *cobra.Command, args []string) error {
-	name := args[0]
+	scope, err := git.ParseScope(useGitScope)
+	if err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	if err := checkAgentLifetime(useAgentLifetime); err != nil {
+		printErr("%v", err)
+		return err
+	}
+
+	name := optionalArg(args)
+	switch name {
+	case "":
+		if name, err = pickContext(); err != nil {
+			return err
+		}
+	case "-":
+		if name, err = previousContext(); err != nil {
+			return err
+		}
+	}
+	return switchContext(name, switchOptions{
+		Scope:         scope,
+		Agent:         useAgent || useAgentLifetime > 0,
+		AgentLifetime: useAgentLifetime,
+		Verify:        !useNoVerify,
+	})
+}
+
+// checkAgentLifetime rejects --agent-lifetime values ssh-agent can't honor:
+// it counts whole seconds in 32 bits, and 0 would mean "forever".
+func checkAgentLifetime(lifetime time.Duration) error {
+	switch {
+	case lifetime < 0:
+		return fmt.Errorf("--agent-lifetime must not be negative, got %s", lifetime)
+	case lifetime > 0 && lifetime < time.Second:
+		return fmt.Errorf("--agent-lifetime must be at least 1s, got %s", lifetime)
+	case lifetime > math.MaxUint32*time.Second:
+		return fmt.Errorf("--agent-lifetime is too long: %s", lifetime)
+	}
+	return nil
+}
+
+// previousContext returns the context that was active before the current one.
+func previousContext() (string, error) {
+	previous, err := config.GetPrevious()
+	if err != nil {
+		return "", err
+	}
+	if previous == "" {
+		printErr("No previous context to switch back to")
+		return "", fmt.Errorf("no previous context")
+	}
+	return previous, nil
+}
+
+// switchContext activates the named context, writing its git identity to opts.Scope.
+func switchContext(name string, opts switchOptions) error {
+	// Serialize with other switches, e.g. shell hooks firing in several terminals
+	lock, err := config.LockSwitch()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
 
 	// Load context to verify it exists
 	ctx, loadErr := config.Load(name)
 	if loadErr != nil {
+		if exists, _ := config.Exists(name); exists {
+			printErr("Cannot load context '%s': %v", name, loadErr)
+			return loadErr
+		}
+
 		// Context not found - show available contexts
 		contexts, listErr := config.List()
 		if listErr == nil && len(contexts) > 0 {
@@ -40,6 +162,10 @@ func runUse(cmd *cobra.Command, args []string) error {
 		return loadErr
 	}
 
+	// Remember the outgoing context so its git identity can be reverted
+	previous, _ := config.GetActive()
+	previousScope, _ := config.GetActiveScope()
+
 	// Set context immediately (fast by default)
 	if err := config.SetActive(name); err != nil {
 		return err
@@ -48,27 +174,44 @@ func runUse(cmd *cobra.Command, args []string) error {
 	printOk("Switched to context '%s' (%s@%s)", name, ctx.User, ctx.Hostname)
 
 	// Activate SSH key if configured
-	if ctx.SSHKey != "" && ctx.Transport == "ssh" {
+	if ctx.SSHKey != "" && ctx.Transport == "ssh" && !ctx.UsesSSHAlias() {
 		printInfo("Activating SSH key: %s", ctx.SSHKey)
 
-		sshCfg, err := ssh.ParseConfig("")
-		if err != nil {
-			printErr("Failed to read SSH config: %v", err)
-		} else {
-			err = sshCfg.ActivateKey(ctx.Hostname, ctx.SSHKey)
-			if err != nil {
-				printErr("Failed to activate SSH key: %v", err)
-				printInfo("You may need to manually update your ~/.ssh/config")
-			} else {
-				if err := sshCfg.Save(); err != nil {
-					printErr("Failed to save SSH config: %v", err)
-				} else {
-					printOk("SSH config updated (backup saved to ~/.ssh/config.bak)")
-				}
-			}
+		var activateErr error
+		err := ssh.Update("", func(sshCfg *ssh.ConfigFile) (bool, error) {
+			activateErr = sshCfg.ActivateKey(ctx.Hostname, ctx.SSHKey)
+			return activateErr == nil, nil
+		})
+		switch {
+		case err != nil:
+			printErr("Failed to update SSH config: %v", err)
+		case activateErr != nil:
+			printErr("Failed to activate SSH key: %v", activateErr)
+			printInfo("You may need to manually update your ~/.ssh/config")
+		default:
+			printOk("SSH config updated (previous version in 'gh context ssh backups')")
 		}
 	}
 
+	// Point git URLs at the context's managed SSH alias, or away from other aliases
+	activateSSHAlias(ctx)
+
+	if opts.Agent {
+		syncAgent(ctx, opts.AgentLifetime)
+	}
+
+	if !opts.KeepIdentity {
+		switchGitIdentity(previous, previousScope, ctx, opts.Scope)
+	}
+
+	switchGitCredentials(previous, ctx)
+
+	if opts.Verify && ctx.Transport == "ssh" && ctx.SSHKey != "" {
+		printInfo("Verifying SSH key...")
+		login, err := (&ssh.Probe{Timeout: 5 * time.Second}).Identity(ctx.Hostname, ctx.SSHKey)
+		reportSSHLogin(ctx, login, err)
+	}
+
 	// Test if authentication works
 	printInfo("Testing authentication...")
 	authenticated, testErr := auth.TestAuth(ctx.Hostname, ctx.User)
@@ -89,3 +232,189 @@ func runUse(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// syncAgent loads the context's SSH key into ssh-agent and unloads the keys of
+// every other context. Failures are reported but don't abort the switch.
+func syncAgent(ctx *config.Context, lifetime time.Duration) {
+	if ctx.Transport != "ssh" || ctx.SSHKey == "" {
+		printInfo("Skipping ssh-agent: context '%s' has no SSH key", ctx.Name)
+		return
+	}
+
+	agent, err := ssh.ConnectAgent()
+	if err != nil {
+		printErr("%v", err)
+		printInfo("Start one with: eval \"$(ssh-agent)\"")
+		return
+	}
+	defer agent.Close()
+
+	contexts, err := config.ListContexts()
+	if err != nil {
+		printErr("Cannot list contexts: %v", err)
+		return
+	}
+	syncAgentKeys(agent, ctx, contexts, lifetime)
+}
+
+// syncAgentKeys unloads the keys of contexts other than ctx from agent, then
+// loads ctx's key. Keys shared with ctx stay loaded.
+func syncAgentKeys(agent *ssh.Agent, ctx *config.Context, contexts []*config.Context, lifetime time.Duration) {
+	want := ssh.ExpandPath(ctx.SSHKey)
+	for _, other := range contexts {
+		if other.SSHKey == "" || ssh.ExpandPath(other.SSHKey) == want {
+			continue
+		}
+		removed, err := agent.RemoveKey(other.SSHKey)
+		if err != nil {
+			printErr("Failed to unload %s from ssh-agent: %v", other.SSHKey, err)
+		} else if removed {
+			printOk("Unloaded %s (%s) from ssh-agent", other.SSHKey, other.Name)
+		}
+	}
+
+	if err := agent.AddKey(ctx.SSHKey, lifetime); err != nil {
+		printErr("Failed to load %s into ssh-agent: %v", ctx.SSHKey, err)
+		return
+	}
+	if lifetime > 0 {
+		printOk("Loaded %s into ssh-agent for %s", ctx.SSHKey, lifetime)
+	} else {
+		printOk("Loaded %s into ssh-agent", ctx.SSHKey)
+	}
+}
+
+// switchGitIdentity reverts the previous context's git identity, in the scope
+// it was written to, and applies the new one. The user's own values are saved
+// before the first context identity overwrites them, and restored when
+// switching to a context without one. The scope is recorded for the next switch.
+func switchGitIdentity(previous string, previousScope config.ActiveScope, ctx *config.Context, scope git.Scope) {
+	if previous != "" && previous != ctx.Name {
+		if prevCtx, err := config.Load(previous); err == nil && prevCtx.HasGitIdentity() {
+			if prevScope, ok := reachableScope(previousScope, scope); ok {
+				if err := revertGitIdentity(prevScope, prevCtx); err != nil {
+					printErr("Failed to revert git identity of '%s': %v", previous, err)
+				}
+			}
+		}
+	}
+
+	repo, err := identityRepo(scope)
+	if err != nil {
+		if ctx.HasGitIdentity() {
+			printErr("Cannot write git identity to %s config: %v", scope, err)
+		}
+		return
+	}
+	if err := config.SetActiveScope(config.ActiveScope{Scope: string(scope), Repo: repo}); err != nil {
+		printErr("Cannot record the git scope of '%s': %v", ctx.Name, err)
+	}
+
+	if !ctx.HasGitIdentity() {
+		return
+	}
+
+	prior, err := git.ReadIdentityConfig(scope)
+	if err == nil {
+		err = config.SavePriorIdentity(string(scope), repo, prior)
+	}
+	if err != nil {
+		printErr("Cannot save the current git identity: %v", err)
+		return
+	}
+
+	if err := git.ApplyIdentity(scope, gitIdentity(ctx)); err != nil {
+		printErr("Failed to apply git identity: %v", err)
+		return
+	}
+	printOk("Git identity set in %s config (%s)", scope, gitIdentity(ctx))
+}
+
+// reachableScope returns the git config an identity recorded with recorded
+// lives in, if this process can write it: global config always, a repo's
+// local config only from inside that repo. Identities applied before scopes
+// were recorded are assumed to be in fallback.
+func reachableScope(recorded config.ActiveScope, fallback git.Scope) (git.Scope, bool) {
+	switch git.Scope(recorded.Scope) {
+	case "":
+		return fallback, true
+	case git.ScopeLocal:
+		root, err := git.RepoRoot()
+		return git.ScopeLocal, err == nil && root != "" && root == recorded.Repo
+	default:
+		return git.ScopeGlobal, true
+	}
+}
+
+// revertGitIdentity undoes ctx's git identity in scope, restoring the values
+// saved before it was applied.
+func revertGitIdentity(scope git.Scope, ctx *config.Context) error {
+	repo, err := identityRepo(scope)
+	if err != nil {
+		return err
+	}
+	prior, err := config.LoadPriorIdentity(string(scope), repo)
+	if err != nil {
+		return err
+	}
+	if err := git.RevertIdentity(scope, gitIdentity(ctx), prior); err != nil {
+		return err
+	}
+	return config.ForgetPriorIdentity(string(scope), repo)
+}
+
+// identityRepo returns the repository whose config scope refers to: the
+// current one for local scope, none for global.
+func identityRepo(scope git.Scope) (string, error) {
+	if scope != git.ScopeLocal {
+		return "", nil
+	}
+	root, err := git.RepoRoot()
+	if err == nil && root == "" {
+		err = fmt.Errorf("not in a git repository")
+	}
+	return root, err
+}
+
+// switchGitCredentials reverts the previous context's HTTPS credential username
+// and, for https contexts, routes git's HTTPS credentials for the host through
+// gh as the context's user. Like URL rewrites, these are always global. The
+// gh helper stays when switching away: it follows gh's active account.
+func switchGitCredentials(previous string, ctx *config.Context) {
+	if previous != "" && previous != ctx.Name {
+		if prevCtx, err := config.Load(previous); err == nil && prevCtx.Transport == "https" {
+			if err := git.RevertCredentialUser(git.ScopeGlobal, prevCtx.Hostname, prevCtx.User); err != nil {
+				printErr("Failed to revert git credentials of '%s': %v", previous, err)
+			}
+		}
+	}
+
+	if ctx.Transport != "https" {
+		return
+	}
+
+	helper, err := auth.GitCredentialHelper()
+	if err != nil {
+		printErr("Cannot set up git credentials: %v", err)
+		return
+	}
+	if _, err := git.EnsureCredentialHelper(git.ScopeGlobal, ctx.Hostname, helper); err != nil {
+		printErr("Failed to set git credential helper: %v", err)
+		return
+	}
+	if err := git.SetCredentialUser(git.ScopeGlobal, ctx.Hostname, ctx.User); err != nil {
+		printErr("Failed to set git credential username: %v", err)
+		return
+	}
+	printOk("git over https://%s now uses %s's gh token", ctx.Hostname, ctx.User)
+}
+
+// gitIdentity converts a context's git settings into a git.Identity.
+func gitIdentity(ctx *config.Context) git.Identity {
+	return git.Identity{
+		Name:          ctx.GitName,
+		Email:         ctx.GitEmail,
+		SigningKey:    ctx.GitSigningKey,
+		SigningFormat: ctx.GitSigningFormat,
+	}
+}
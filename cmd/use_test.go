@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// writeTestKey creates an unencrypted ed25519 keypair at dir/name(.pub).
+func writeTestKey(t *testing.T, dir, name string) string {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := gossh.MarshalPrivateKey(priv, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshPub, err := gossh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".pub", gossh.MarshalAuthorizedKey(sshPub), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSyncAgentKeys(t *testing.T) {
+	dir := t.TempDir()
+	workKey := writeTestKey(t, dir, "id_work")
+	personalKey := writeTestKey(t, dir, "id_personal")
+	ossKey := writeTestKey(t, dir, "id_oss")
+
+	work := &config.Context{Name: "work", Transport: "ssh", SSHKey: workKey}
+	personal := &config.Context{Name: "personal", Transport: "ssh", SSHKey: personalKey}
+	oss := &config.Context{Name: "oss", Transport: "ssh", SSHKey: ossKey}
+	// Shares the work key, so switching to work must not unload it
+	workAlt := &config.Context{Name: "work-alt", Transport: "ssh", SSHKey: workKey}
+	web := &config.Context{Name: "web", Transport: "https"}
+	contexts := []*config.Context{oss, personal, web, work, workAlt}
+
+	a := ssh.NewAgent(agent.NewKeyring())
+	for _, key := range []string{workKey, personalKey} {
+		if err := a.AddKey(key, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	syncAgentKeys(a, oss, contexts, 0)
+	assertAgentKeys(t, a, map[string]bool{ossKey: true, personalKey: false, workKey: false})
+
+	syncAgentKeys(a, work, contexts, 0)
+	assertAgentKeys(t, a, map[string]bool{ossKey: false, personalKey: false, workKey: true})
+
+	syncAgentKeys(a, workAlt, contexts, 0)
+	assertAgentKeys(t, a, map[string]bool{ossKey: false, personalKey: false, workKey: true})
+}
+
+func assertAgentKeys(t *testing.T, a *ssh.Agent, want map[string]bool) {
+	t.Helper()
+	for key, loaded := range want {
+		got, err := a.HasKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != loaded {
+			t.Errorf("%s loaded = %v, want %v", filepath.Base(key), got, loaded)
+		}
+	}
+}
+
+func TestCheckAgentLifetime(t *testing.T) {
+	for _, tt := range []struct {
+		lifetime string
+		ok       bool
+	}{
+		{"0s", true},
+		{"1s", true},
+		{"8h", true},
+		{"-1s", false},
+		{"500ms", false},
+		{"200000h", true},
+		{"2000000h", false},
+	} {
+		d, err := time.ParseDuration(tt.lifetime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := checkAgentLifetime(d); (err == nil) != tt.ok {
+			t.Errorf("checkAgentLifetime(%s) = %v, want ok=%v", tt.lifetime, err, tt.ok)
+		}
+	}
+}
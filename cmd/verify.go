@@ -0,0 +1,122 @@
+// ABOUTME: Verify command for gh-context - checks which account each SSH key logs in as
+// ABOUTME: Handshakes with git@<host> using only the context key and compares with the context user
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [name...]",
+	Short: "Check that a context's SSH key authenticates as its user",
+	Long: `Connect to git@<hostname> with only the context's SSH key, read the account
+GitHub greets ("Hi <user>!") and compare it with the context's user.
+
+gh auth only proves the token's account; a key registered to another account
+still pushes as that account. Host keys are checked against known_hosts.
+
+Without names, the active context is verified; --all verifies every ssh
+context. Exits 1 if any key fails or authenticates as someone else.
+
+Examples:
+  gh context verify
+  gh context verify work personal
+  gh context verify --all
+  gh context verify work --addr ssh.github.com:443`,
+	ValidArgsFunction: completeContextNames(0),
+	RunE:              runVerify,
+}
+
+var (
+	verifyAll     bool
+	verifyAddr    string
+	verifyTimeout time.Duration
+)
+
+func init() {
+	verifyCmd.Flags().BoolVar(&verifyAll, "all", false, "Verify every context with an SSH key")
+	verifyCmd.Flags().StringVar(&verifyAddr, "addr", "", "host:port to connect to instead of the context's hostname")
+	verifyCmd.Flags().DurationVar(&verifyTimeout, "timeout", 10*time.Second, "Give up on a host after this long")
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	names := args
+	switch {
+	case verifyAll && len(args) > 0:
+		return fmt.Errorf("--all does not take context names")
+	case verifyAll:
+		all, err := config.List()
+		if err != nil {
+			return err
+		}
+		names = all
+	case len(names) == 0:
+		active, err := config.GetActive()
+		if err != nil {
+			return err
+		}
+		if active == "" {
+			printErr("No active context; pass a context name")
+			return fmt.Errorf("no active context")
+		}
+		names = []string{active}
+	}
+
+	probe := &ssh.Probe{Addr: verifyAddr, Timeout: verifyTimeout}
+	failed := 0
+	for _, name := range names {
+		ctx, err := config.Load(name)
+		if err != nil {
+			printErr("%v", err)
+			failed++
+			continue
+		}
+		if ctx.Transport != "ssh" || ctx.SSHKey == "" {
+			if !verifyAll {
+				printInfo("%s: no SSH key to verify (%s transport)", ctx.Name, ctx.Transport)
+			}
+			continue
+		}
+
+		login, err := probe.Identity(ctx.Hostname, ctx.SSHKey)
+		if !reportSSHLogin(ctx, login, err) {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return &exitCodeError{code: 1}
+	}
+	return nil
+}
+
+// reportSSHLogin prints the outcome of an SSH login probe for ctx and reports
+// whether the key authenticates as the context's user. A mismatch means git
+// pushes as another account, so it gets more than one line.
+func reportSSHLogin(ctx *config.Context, login string, err error) bool {
+	switch {
+	case errors.Is(err, ssh.ErrKeyRejected):
+		printErr("%s: SSH key %s is not registered to any account on %s", ctx.Name, ctx.SSHKey, ctx.Hostname)
+		printInfo("Add %s at https://%s/settings/keys while logged in as %s", ssh.PublicKeyPath(ctx.SSHKey), ctx.Hostname, ctx.User)
+		return false
+	case err != nil:
+		printErr("%s: cannot verify SSH key: %v", ctx.Name, err)
+		return false
+	case !strings.EqualFold(login, ctx.User):
+		printErr("%s: SSH KEY MISMATCH: %s authenticates as '%s', not '%s'", ctx.Name, ctx.SSHKey, login, ctx.User)
+		printInfo("git over SSH will act as '%s' while gh acts as '%s'", login, ctx.User)
+		printInfo("Use a key registered to %s, or remove this key from the %s account", ctx.User, login)
+		return false
+	default:
+		printOk("%s: SSH key %s authenticates as '%s'", ctx.Name, ctx.SSHKey, login)
+		return true
+	}
+}
@@ -1,72 +1,48 @@
 // ABOUTME: GitHub CLI authentication operations for gh-context
-// ABOUTME: Wraps gh auth commands for testing and switching accounts
+// ABOUTME: Checks, switches and reads gh accounts through a Provider
 
 package auth
 
 import (
-	"context"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/exec"
 	"strings"
-	"time"
 
 	"github.com/cli/go-gh/v2"
 	"github.com/cli/go-gh/v2/pkg/api"
+	ghAuth "github.com/cli/go-gh/v2/pkg/auth"
 )
 
-// TestAuth checks if the given user is authenticated on the given host.
-// Returns true if authentication is valid and ready to use.
+// TestAuth checks that gh is logged in as user on hostname, makes user gh's
+// active account there, and confirms with the API that the account's token
+// authenticates as user. Returns true if everything is ready to use.
 func TestAuth(hostname, user string) (bool, error) {
-	// Check if the user has authentication for this host
-	stdout, _, err := gh.Exec("auth", "status", "--hostname", hostname)
-	if err != nil {
-		return false, nil // Not authenticated at all
-	}
+	return testAuth(Default, hostname, user)
+}
 
-	output := stdout.String()
-	expectedPattern := fmt.Sprintf("Logged in to %s account %s", hostname, user)
-	if !strings.Contains(output, expectedPattern) {
-		return false, nil // Different user or not logged in
+func testAuth(p Provider, hostname, user string) (bool, error) {
+	if !isUserLoggedIn(p, hostname, user) {
+		return false, nil // Not logged in as this user
 	}
 
-	// Try to switch to the user
-	_, _, err = gh.Exec("auth", "switch", "--hostname", hostname, "--user", user)
-	if err != nil {
-		return false, nil // Switch failed
+	if active, err := p.ActiveUser(hostname); err != nil || !strings.EqualFold(active, user) {
+		if err := p.Switch(hostname, user); err != nil {
+			return false, nil // Switch failed
+		}
 	}
 
-	// Verify with a quick API call
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	currentUser, err := getCurrentUser(ctx, hostname)
+	token, err := p.Token(hostname, user)
 	if err != nil {
 		return false, nil
 	}
-
-	return currentUser == user, nil
-}
-
-// getCurrentUser fetches the current authenticated user via API.
-func getCurrentUser(ctx context.Context, hostname string) (string, error) {
-	opts := api.ClientOptions{
-		Host: hostname,
-	}
-	client, err := api.NewRESTClient(opts)
+	login, err := p.Login(hostname, token)
 	if err != nil {
-		return "", err
-	}
-
-	var response struct {
-		Login string `json:"login"`
-	}
-
-	err = client.Get("user", &response)
-	if err != nil {
-		return "", err
+		return false, nil
 	}
-
-	return response.Login, nil
+	return strings.EqualFold(login, user), nil
 }
 
 // GetCurrentUserFromSession gets the current user from the active gh session.
@@ -93,8 +69,7 @@ func GetCurrentUserFromSession(hostname string) (string, error) {
 
 // SwitchUser switches the gh CLI to use a specific user on a host.
 func SwitchUser(hostname, user string) error {
-	_, _, err := gh.Exec("auth", "switch", "--hostname", hostname, "--user", user)
-	return err
+	return Default.Switch(hostname, user)
 }
 
 // HasToken checks if there's an auth token for the given host.
@@ -103,26 +78,110 @@ func HasToken(hostname string) bool {
 	return err == nil
 }
 
-// GetAuthStatus returns raw auth status output for a hostname.
-func GetAuthStatus(hostname string) (string, error) {
-	stdout, stderr, err := gh.Exec("auth", "status", "--hostname", hostname)
+// Token returns the stored gh token for a specific user on a host.
+// Token environment variables are stripped so the keyring entry is always read,
+// even from a shell where another context's GH_TOKEN is exported.
+func Token(hostname, user string) (string, error) {
+	ghExe, err := gh.Path()
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(ghExe, "auth", "token", "--hostname", hostname, "--user", user)
+	cmd.Env = withoutTokenEnv(os.Environ())
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("no token for %s@%s: %s", user, hostname, msg)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// GitCredentialHelper returns the git credential helper that answers with gh's
+// token for the requested host, as written by 'gh auth setup-git'.
+func GitCredentialHelper() (string, error) {
+	ghExe, err := gh.Path()
 	if err != nil {
-		// gh auth status returns non-zero if not logged in, but still outputs info
-		return stderr.String(), nil
+		return "", err
 	}
-	return stdout.String(), nil
+	if strings.ContainsAny(ghExe, " \t'\"") {
+		ghExe = "'" + strings.ReplaceAll(ghExe, "'", `'\''`) + "'"
+	}
+	return "!" + ghExe + " auth git-credential", nil
 }
 
-// IsUserLoggedIn checks if a specific user is logged in on a host.
+// AddSSHKey uploads a public key as an authentication key of a specific user,
+// using that user's stored token rather than whichever account gh has active.
+func AddSSHKey(hostname, user, pubKeyPath, title string) error {
+	token, err := Token(hostname, user)
+	if err != nil {
+		return err
+	}
+	ghExe, err := gh.Path()
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(ghExe, "ssh-key", "add", pubKeyPath, "--title", title, "--type", "authentication")
+	cmd.Env = append(withoutTokenEnv(os.Environ()), "GH_HOST="+hostname, TokenEnvVar(hostname)+"="+token)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("gh ssh-key add failed: %s", msg)
+	}
+	return nil
+}
+
+// TokenEnvVar returns the variable gh reads a token for hostname from:
+// GH_ENTERPRISE_TOKEN for GitHub Enterprise Server, GH_TOKEN for github.com
+// and GitHub Enterprise Cloud (*.ghe.com) hosts.
+func TokenEnvVar(hostname string) string {
+	if ghAuth.IsEnterprise(hostname) {
+		return "GH_ENTERPRISE_TOKEN"
+	}
+	return "GH_TOKEN"
+}
+
+// withoutTokenEnv removes gh token overrides from an environment list.
+func withoutTokenEnv(env []string) []string {
+	filtered := make([]string, 0, len(env))
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "GH_TOKEN=") || strings.HasPrefix(kv, "GITHUB_TOKEN=") ||
+			strings.HasPrefix(kv, "GH_ENTERPRISE_TOKEN=") || strings.HasPrefix(kv, "GITHUB_ENTERPRISE_TOKEN=") {
+			continue
+		}
+		filtered = append(filtered, kv)
+	}
+	return filtered
+}
+
+// IsUserLoggedIn checks if a specific user is logged in on a host, according
+// to gh's hosts.yml. No subprocess or network call is made.
 func IsUserLoggedIn(hostname, user string) bool {
-	stdout, _, err := gh.Exec("auth", "status", "--hostname", hostname)
+	return isUserLoggedIn(Default, hostname, user)
+}
+
+func isUserLoggedIn(p Provider, hostname, user string) bool {
+	users, err := p.Users(hostname)
 	if err != nil {
 		return false
 	}
-
-	output := stdout.String()
-	expectedPattern := fmt.Sprintf("Logged in to %s account %s", hostname, user)
-	return strings.Contains(output, expectedPattern)
+	for _, u := range users {
+		if strings.EqualFold(u, user) {
+			return true
+		}
+	}
+	return false
 }
 
 // VerifyConnectivity tests that we can reach the GitHub API on the given host.
@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	ghConfig "github.com/cli/go-gh/v2/pkg/config"
+)
+
+// fakeProvider is an in-memory Provider for one host.
+type fakeProvider struct {
+	users     []string
+	active    string
+	tokens    map[string]string // user -> token
+	logins    map[string]string // token -> login the API reports
+	switchErr error
+	switched  []string
+}
+
+func (f *fakeProvider) Users(hostname string) ([]string, error) { return f.users, nil }
+
+func (f *fakeProvider) ActiveUser(hostname string) (string, error) { return f.active, nil }
+
+func (f *fakeProvider) Token(hostname, user string) (string, error) {
+	for u, token := range f.tokens {
+		if strings.EqualFold(u, user) {
+			return token, nil
+		}
+	}
+	return "", errors.New("no token")
+}
+
+func (f *fakeProvider) Switch(hostname, user string) error {
+	if f.switchErr != nil {
+		return f.switchErr
+	}
+	f.switched = append(f.switched, user)
+	f.active = user
+	return nil
+}
+
+func (f *fakeProvider) Login(hostname, token string) (string, error) {
+	login, ok := f.logins[token]
+	if !ok {
+		return "", errors.New("401 Unauthorized")
+	}
+	return login, nil
+}
+
+func TestTestAuth(t *testing.T) {
+	tests := []struct {
+		name         string
+		provider     *fakeProvider
+		user         string
+		want         bool
+		wantSwitched []string
+	}{
+		{
+			name: "listed user already active",
+			provider: &fakeProvider{
+				users: []string{"work", "personal"}, active: "work",
+				tokens: map[string]string{"work": "t-work"}, logins: map[string]string{"t-work": "work"},
+			},
+			user: "work",
+			want: true,
+		},
+		{
+			name: "listed user is switched to",
+			provider: &fakeProvider{
+				users: []string{"work", "personal"}, active: "work",
+				tokens: map[string]string{"personal": "t-personal"}, logins: map[string]string{"t-personal": "personal"},
+			},
+			user:         "personal",
+			want:         true,
+			wantSwitched: []string{"personal"},
+		},
+		{
+			name: "legacy single user",
+			provider: &fakeProvider{
+				users: []string{"work"}, active: "work",
+				tokens: map[string]string{"work": "t-work"}, logins: map[string]string{"t-work": "work"},
+			},
+			user: "work",
+			want: true,
+		},
+		{
+			name: "case-insensitive match",
+			provider: &fakeProvider{
+				users: []string{"Work-User"}, active: "Work-User",
+				tokens: map[string]string{"Work-User": "t-work"}, logins: map[string]string{"t-work": "Work-User"},
+			},
+			user: "work-user",
+			want: true,
+		},
+		{
+			name: "user not logged in",
+			provider: &fakeProvider{
+				users: []string{"work"}, active: "work",
+				tokens: map[string]string{"work": "t-work"}, logins: map[string]string{"t-work": "work"},
+			},
+			user: "personal",
+			want: false,
+		},
+		{
+			name: "switch fails",
+			provider: &fakeProvider{
+				users: []string{"work", "personal"}, active: "work",
+				tokens:    map[string]string{"personal": "t-personal"},
+				logins:    map[string]string{"t-personal": "personal"},
+				switchErr: errors.New("gh auth switch failed"),
+			},
+			user: "personal",
+			want: false,
+		},
+		{
+			name: "token logs in as someone else",
+			provider: &fakeProvider{
+				users: []string{"work"}, active: "work",
+				tokens: map[string]string{"work": "t-work"}, logins: map[string]string{"t-work": "personal"},
+			},
+			user: "work",
+			want: false,
+		},
+		{
+			name: "token rejected",
+			provider: &fakeProvider{
+				users: []string{"work"}, active: "work",
+				tokens: map[string]string{"work": "t-expired"}, logins: map[string]string{},
+			},
+			user: "work",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testAuth(tt.provider, "github.com", tt.user)
+			if err != nil {
+				t.Fatalf("testAuth error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("testAuth = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.provider.switched, tt.wantSwitched) {
+				t.Errorf("switched to %v, want %v", tt.provider.switched, tt.wantSwitched)
+			}
+		})
+	}
+}
+
+// ghProviderFrom returns a GhProvider that reads hostsYAML instead of gh's config.
+func ghProviderFrom(hostsYAML string) *GhProvider {
+	p := &GhProvider{}
+	p.once.Do(func() { p.cfg = ghConfig.ReadFromString(hostsYAML) })
+	return p
+}
+
+func TestGhProviderUsers(t *testing.T) {
+	tests := []struct {
+		name   string
+		yaml   string
+		want   []string
+		active string
+	}{
+		{
+			name: "multiple accounts",
+			yaml: `hosts:
+  github.com:
+    git_protocol: ssh
+    users:
+      work:
+      personal:
+    user: personal
+`,
+			want:   []string{"work", "personal"},
+			active: "personal",
+		},
+		{
+			name: "legacy single user",
+			yaml: `hosts:
+  github.com:
+    user: work
+    oauth_token: gho_legacy
+`,
+			want:   []string{"work"},
+			active: "work",
+		},
+		{
+			name: "host not logged in",
+			yaml: `hosts:
+  ghe.example.com:
+    user: work
+`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := ghProviderFrom(tt.yaml)
+			users, err := p.Users("github.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(users, tt.want) {
+				t.Errorf("Users = %v, want %v", users, tt.want)
+			}
+			if active, _ := p.ActiveUser("github.com"); active != tt.active {
+				t.Errorf("ActiveUser = %q, want %q", active, tt.active)
+			}
+		})
+	}
+}
+
+func TestIsUserLoggedIn(t *testing.T) {
+	p := ghProviderFrom(`hosts:
+  github.com:
+    users:
+      Work-User:
+    user: Work-User
+`)
+	for user, want := range map[string]bool{"Work-User": true, "work-user": true, "personal": false} {
+		if got := isUserLoggedIn(p, "github.com", user); got != want {
+			t.Errorf("isUserLoggedIn(%q) = %v, want %v", user, got, want)
+		}
+	}
+}
+
+func TestTokenEnvVar(t *testing.T) {
+	tests := map[string]string{
+		"github.com":          "GH_TOKEN",
+		"GitHub.com":          "GH_TOKEN",
+		"api.github.com":      "GH_TOKEN",
+		"acme.ghe.com":        "GH_TOKEN",
+		"ghe.corp.example":    "GH_ENTERPRISE_TOKEN",
+		"github.example.com":  "GH_ENTERPRISE_TOKEN",
+		"ghe.com.example.org": "GH_ENTERPRISE_TOKEN",
+	}
+	for host, want := range tests {
+		if got := TokenEnvVar(host); got != want {
+			t.Errorf("TokenEnvVar(%q) = %s, want %s", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// ABOUTME: Source of gh authentication state for gh-context
+// ABOUTME: Reads accounts from gh's hosts.yml, tokens from gh auth token, logins from the API
+
+package auth
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cli/go-gh/v2"
+	"github.com/cli/go-gh/v2/pkg/api"
+	ghConfig "github.com/cli/go-gh/v2/pkg/config"
+)
+
+// Provider is where gh-context learns about gh's accounts. The package-level
+// functions use Default; swap in a fake to exercise them without gh.
+type Provider interface {
+	// Users returns the accounts gh is logged in to on hostname.
+	Users(hostname string) ([]string, error)
+	// ActiveUser returns the account gh currently uses on hostname.
+	ActiveUser(hostname string) (string, error)
+	// Token returns the stored token of one account.
+	Token(hostname, user string) (string, error)
+	// Switch makes user gh's active account on hostname.
+	Switch(hostname, user string) error
+	// Login returns the account a token authenticates as, according to the API.
+	Login(hostname, token string) (string, error)
+}
+
+// Default is the Provider backed by the installed gh.
+var Default Provider = &GhProvider{}
+
+// GhProvider reads gh's hosts.yml once per process through go-gh, and runs
+// gh only for tokens (which may live in the system keyring) and switching.
+type GhProvider struct {
+	once sync.Once
+	cfg  *ghConfig.Config
+	err  error
+}
+
+// config returns gh's configuration, reading it on first use.
+func (p *GhProvider) config() (*ghConfig.Config, error) {
+	p.once.Do(func() {
+		p.cfg, p.err = ghConfig.Read(nil)
+	})
+	return p.cfg, p.err
+}
+
+// Users lists hosts.<hostname>.users. Configs written before gh supported
+// several accounts only name the active user.
+func (p *GhProvider) Users(hostname string) ([]string, error) {
+	cfg, err := p.config()
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := cfg.Keys([]string{"hosts", hostname, "users"})
+	var notFound *ghConfig.KeyNotFoundError
+	if errors.As(err, &notFound) {
+		active, err := p.ActiveUser(hostname)
+		if err != nil || active == "" {
+			return nil, err
+		}
+		return []string{active}, nil
+	}
+	return users, err
+}
+
+// ActiveUser reads hosts.<hostname>.user. Returns "" if gh has no account
+// on hostname.
+func (p *GhProvider) ActiveUser(hostname string) (string, error) {
+	cfg, err := p.config()
+	if err != nil {
+		return "", err
+	}
+
+	user, err := cfg.Get([]string{"hosts", hostname, "user"})
+	var notFound *ghConfig.KeyNotFoundError
+	if errors.As(err, &notFound) {
+		return "", nil
+	}
+	return user, err
+}
+
+// Token runs gh auth token --user; see Token.
+func (p *GhProvider) Token(hostname, user string) (string, error) {
+	return Token(hostname, user)
+}
+
+// Switch runs gh auth switch. gh rewrites hosts.yml, so the cached copy is
+// updated to match.
+func (p *GhProvider) Switch(hostname, user string) error {
+	if _, _, err := gh.Exec("auth", "switch", "--hostname", hostname, "--user", user); err != nil {
+		return err
+	}
+	if cfg, err := p.config(); err == nil {
+		cfg.Set([]string{"hosts", hostname, "user"}, user)
+	}
+	return nil
+}
+
+// Login calls GET /user with the token.
+func (p *GhProvider) Login(hostname, token string) (string, error) {
+	client, err := api.NewRESTClient(api.ClientOptions{
+		Host:      hostname,
+		AuthToken: token,
+		Timeout:   5 * time.Second,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Login string `json:"login"`
+	}
+	if err := client.Get("user", &response); err != nil {
+		return "", err
+	}
+	return response.Login, nil
+}
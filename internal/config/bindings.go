@@ -0,0 +1,134 @@
+// ABOUTME: Registry of repositories bound to contexts with .ghcontext files
+// ABOUTME: Kept in the contexts dir so bindings can be listed, audited and pruned
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/peterjmorgan/gh-context/internal/fileutil"
+	"gopkg.in/yaml.v3"
+)
+
+// Binding records that a repository root is bound to a context.
+type Binding struct {
+	Repo    string `yaml:"repo"`
+	Context string `yaml:"context"`
+}
+
+// bindingsDoc is the on-disk form of the registry.
+type bindingsDoc struct {
+	Version  int       `yaml:"version"`
+	Bindings []Binding `yaml:"bindings"`
+}
+
+// ListBindings returns the registered bindings, sorted by repository.
+func ListBindings() ([]Binding, error) {
+	path, err := BindingsFile()
+	if err != nil {
+		return nil, err
+	}
+	return readBindings(path)
+}
+
+// BindingsFor returns the registered bindings that name context.
+func BindingsFor(context string) ([]Binding, error) {
+	all, err := ListBindings()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []Binding
+	for _, b := range all {
+		if b.Context == context {
+			matched = append(matched, b)
+		}
+	}
+	return matched, nil
+}
+
+// RegisterBinding records (or updates) the binding of a repository root.
+func RegisterBinding(repo, context string) error {
+	return updateBindings(func(bindings map[string]string) {
+		bindings[repo] = context
+	})
+}
+
+// UnregisterBinding forgets the binding of a repository root.
+func UnregisterBinding(repo string) error {
+	return updateBindings(func(bindings map[string]string) {
+		delete(bindings, repo)
+	})
+}
+
+// updateBindings applies fn to the registry under the state lock.
+func updateBindings(fn func(bindings map[string]string)) error {
+	path, err := BindingsFile()
+	if err != nil {
+		return err
+	}
+
+	lock, err := lockState()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	list, err := readBindings(path)
+	if err != nil {
+		return err
+	}
+
+	bindings := make(map[string]string, len(list))
+	for _, b := range list {
+		bindings[b.Repo] = b.Context
+	}
+	fn(bindings)
+
+	doc := bindingsDoc{Version: 1, Bindings: []Binding{}}
+	for repo, context := range bindings {
+		doc.Bindings = append(doc.Bindings, Binding{Repo: repo, Context: context})
+	}
+	sortBindings(doc.Bindings)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	return fileutil.WriteFileAtomic(path, buf.Bytes(), 0644)
+}
+
+func readBindings(path string) ([]Binding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var doc bindingsDoc
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil && len(bytes.TrimSpace(data)) > 0 {
+		return nil, fmt.Errorf("invalid bindings registry %s: %w", path, err)
+	}
+
+	sortBindings(doc.Bindings)
+	return doc.Bindings, nil
+}
+
+func sortBindings(bindings []Binding) {
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].Repo < bindings[j].Repo
+	})
+}
@@ -0,0 +1,139 @@
+// ABOUTME: Portable export bundle for gh-context contexts
+// ABOUTME: Converts contexts to and from a versioned YAML/JSON document without tokens
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BundleVersion is the version written by export and the newest one import accepts.
+const BundleVersion = 1
+
+// Bundle is the document written by "gh context export". It never carries
+// tokens: those stay in gh's own credential store.
+type Bundle struct {
+	Version  int             `yaml:"version" json:"version"`
+	Contexts []BundleContext `yaml:"contexts" json:"contexts"`
+}
+
+// BundleContext is one context in a bundle. Paths under the home directory
+// are stored as ~/... so the bundle works on another machine.
+type BundleContext struct {
+	Name             string   `yaml:"name" json:"name"`
+	Hostname         string   `yaml:"hostname" json:"hostname"`
+	User             string   `yaml:"user" json:"user"`
+	Transport        string   `yaml:"transport" json:"transport"`
+	SSHKey           string   `yaml:"ssh_key,omitempty" json:"ssh_key,omitempty"`
+	SSHStrategy      string   `yaml:"ssh_strategy,omitempty" json:"ssh_strategy,omitempty"`
+	GitName          string   `yaml:"git_name,omitempty" json:"git_name,omitempty"`
+	GitEmail         string   `yaml:"git_email,omitempty" json:"git_email,omitempty"`
+	GitSigningKey    string   `yaml:"git_signing_key,omitempty" json:"git_signing_key,omitempty"`
+	GitSigningFormat string   `yaml:"git_signing_format,omitempty" json:"git_signing_format,omitempty"`
+	Bindings         []string `yaml:"bindings,omitempty" json:"bindings,omitempty"` // Repo roots bound to this context
+}
+
+// ExportContext converts a context to its bundle form.
+func ExportContext(c *Context) BundleContext {
+	return BundleContext{
+		Name:             c.Name,
+		Hostname:         c.Hostname,
+		User:             c.User,
+		Transport:        c.Transport,
+		SSHKey:           HomeRelative(c.SSHKey),
+		SSHStrategy:      c.SSHStrategy,
+		GitName:          c.GitName,
+		GitEmail:         c.GitEmail,
+		GitSigningKey:    HomeRelative(c.GitSigningKey),
+		GitSigningFormat: c.GitSigningFormat,
+	}
+}
+
+// Context converts a bundle entry back to a context. Bindings are not part of
+// the context and are left to the caller.
+func (b BundleContext) Context() *Context {
+	strategy := b.SSHStrategy
+	if strategy == SSHStrategyIdentity {
+		strategy = "" // Identity is the default and is not written to .ctx files
+	}
+
+	return &Context{
+		Name:             b.Name,
+		Hostname:         b.Hostname,
+		User:             b.User,
+		Transport:        b.Transport,
+		SSHKey:           b.SSHKey,
+		SSHStrategy:      strategy,
+		GitName:          b.GitName,
+		GitEmail:         b.GitEmail,
+		GitSigningKey:    b.GitSigningKey,
+		GitSigningFormat: b.GitSigningFormat,
+	}
+}
+
+// Marshal encodes the bundle as "yaml" or "json".
+func (b *Bundle) Marshal(format string) ([]byte, error) {
+	switch format {
+	case "yaml":
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(b); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case "json":
+		data, err := json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	default:
+		return nil, fmt.Errorf("format must be 'yaml' or 'json', got: %s", format)
+	}
+}
+
+// ParseBundle decodes a YAML or JSON bundle (JSON is valid YAML). Unknown
+// keys are rejected so typos don't silently drop settings.
+func ParseBundle(data []byte) (*Bundle, error) {
+	var b Bundle
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&b); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	if b.Version < 1 || b.Version > BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (this gh-context reads up to version %d)", b.Version, BundleVersion)
+	}
+	return &b, nil
+}
+
+// HomeRelative rewrites a path under the home directory as ~/...
+// Other values (including GPG key IDs) are returned unchanged.
+func HomeRelative(path string) string {
+	if path == "" || strings.HasPrefix(path, "~") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+
+	rel, err := filepath.Rel(home, path)
+	if err != nil || !filepath.IsAbs(path) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return "~/" + filepath.ToSlash(rel)
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHomeRelative(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", ""},
+		{filepath.Join(home, ".ssh", "id_work"), "~/.ssh/id_work"},
+		{filepath.Join(home, "src", "app"), "~/src/app"},
+		{"~/.ssh/id_work", "~/.ssh/id_work"},
+		{filepath.Join(filepath.Dir(home), "other", "id_work"), filepath.Join(filepath.Dir(home), "other", "id_work")},
+		{home + "-sibling/key", home + "-sibling/key"},
+		{"relative/key", "relative/key"},
+		{"3AA5C34371567BD2", "3AA5C34371567BD2"}, // GPG key ID
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := HomeRelative(tt.path); got != tt.want {
+				t.Errorf("HomeRelative(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	contexts := []*Context{
+		{
+			Name:             "work",
+			Hostname:         "github.com",
+			User:             "work-user",
+			Transport:        "ssh",
+			SSHKey:           filepath.Join(home, ".ssh", "id_work"),
+			SSHStrategy:      SSHStrategyAlias,
+			GitName:          "Work User",
+			GitEmail:         "work@example.com",
+			GitSigningKey:    filepath.Join(home, ".ssh", "id_work.pub"),
+			GitSigningFormat: "ssh",
+		},
+		{Name: "personal", Hostname: "github.com", User: "me", Transport: "https", GitSigningKey: "3AA5C34371567BD2"},
+	}
+
+	for _, format := range []string{"yaml", "json"} {
+		t.Run(format, func(t *testing.T) {
+			bundle := &Bundle{Version: BundleVersion}
+			for _, ctx := range contexts {
+				bundle.Contexts = append(bundle.Contexts, ExportContext(ctx))
+			}
+			bundle.Contexts[0].Bindings = []string{HomeRelative(filepath.Join(home, "src", "app"))}
+
+			data, err := bundle.Marshal(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(data), home) {
+				t.Errorf("bundle contains the home directory:\n%s", data)
+			}
+			if strings.Contains(strings.ToLower(string(data)), "token") {
+				t.Errorf("bundle mentions a token:\n%s", data)
+			}
+
+			parsed, err := ParseBundle(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parsed.Contexts) != len(contexts) {
+				t.Fatalf("parsed %d contexts, want %d", len(parsed.Contexts), len(contexts))
+			}
+			if got := parsed.Contexts[0].Bindings; !reflect.DeepEqual(got, []string{"~/src/app"}) {
+				t.Errorf("bindings = %q, want [~/src/app]", got)
+			}
+
+			// Paths come back ~-relative, which the rest of gh-context expands
+			want := *contexts[0]
+			want.SSHKey = "~/.ssh/id_work"
+			want.GitSigningKey = "~/.ssh/id_work.pub"
+			if got := parsed.Contexts[0].Context(); *got != want {
+				t.Errorf("context = %+v, want %+v", *got, want)
+			}
+			if got := parsed.Contexts[1].Context(); *got != *contexts[1] {
+				t.Errorf("context = %+v, want %+v", *got, *contexts[1])
+			}
+		})
+	}
+}
+
+func TestBundleIdentityStrategy(t *testing.T) {
+	ctx := &Context{Name: "work", Hostname: "github.com", User: "u", Transport: "ssh", SSHKey: "~/.ssh/id_work", SSHStrategy: SSHStrategyIdentity}
+	if got := ExportContext(ctx).Context(); got.SSHStrategy != "" {
+		t.Errorf("identity strategy imported as %q, want empty (the default)", got.SSHStrategy)
+	}
+}
+
+func TestParseBundleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"token field", "version: 1\ncontexts:\n  - name: work\n    hostname: github.com\n    user: u\n    transport: https\n    token: ghp_secret\n"},
+		{"unknown top-level key", "version: 1\ncontexts: []\nextra: true\n"},
+		{"missing version", "contexts: []\n"},
+		{"newer version", "version: 2\ncontexts: []\n"},
+		{"not a bundle", "- just\n- a list\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseBundle([]byte(tt.data)); err == nil {
+				t.Errorf("ParseBundle accepted %q", tt.data)
+			}
+		})
+	}
+}
+
+func TestMarshalUnknownFormat(t *testing.T) {
+	if _, err := (&Bundle{Version: BundleVersion}).Marshal("xml"); err == nil {
+		t.Error("Marshal(xml) succeeded")
+	}
+}
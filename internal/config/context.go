@@ -1,14 +1,14 @@
 // ABOUTME: Context struct definition and serialization for gh-context
-// ABOUTME: Handles reading/writing context configuration files (KEY=VALUE format)
+// ABOUTME: Handles reading/writing context configuration files (versioned YAML format)
 
 package config
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"regexp"
-	"strings"
+
+	"github.com/peterjmorgan/gh-context/internal/fileutil"
 )
 
 // Context represents a saved GitHub CLI context (account/host configuration).
@@ -18,6 +18,24 @@ type Context struct {
 	User      string // GitHub username
 	Transport string // ssh or https
 	SSHKey    string // Path to SSH key (e.g., ~/.ssh/id_personal)
+
+	SSHStrategy string // identity (toggle IdentityFile lines) or alias (managed Host alias)
+
+	GitName          string // git user.name applied on switch
+	GitEmail         string // git user.email applied on switch
+	GitSigningKey    string // git user.signingkey (GPG key ID or SSH public key path)
+	GitSigningFormat string // gpg or ssh
+}
+
+// SSH key switching strategies.
+const (
+	SSHStrategyIdentity = "identity" // Comment/uncomment IdentityFile lines in the user's Host block
+	SSHStrategyAlias    = "alias"    // Managed Host <hostname>-<context> alias plus git URL rewrite
+)
+
+// UsesSSHAlias reports whether the context switches keys through a managed Host alias.
+func (c *Context) UsesSSHAlias() bool {
+	return c.SSHStrategy == SSHStrategyAlias
 }
 
 // validNamePattern defines valid context name characters.
@@ -31,82 +49,93 @@ func ValidateName(name string) error {
 	return nil
 }
 
-// Load reads a context from a .ctx file.
+// Validate checks that the context's fields are consistent. It does not touch
+// the filesystem, so a missing SSH key is not an error here.
+func (c *Context) Validate() error {
+	if err := ValidateName(c.Name); err != nil {
+		return err
+	}
+	if c.Hostname == "" || c.User == "" {
+		return fmt.Errorf("context '%s' needs both a hostname and a user", c.Name)
+	}
+
+	switch c.Transport {
+	case "ssh", "https":
+		// Valid
+	default:
+		return fmt.Errorf("transport must be 'ssh' or 'https', got: %s", c.Transport)
+	}
+	if c.Transport == "ssh" && c.SSHKey == "" {
+		return fmt.Errorf("context '%s' uses ssh transport but has no SSH key", c.Name)
+	}
+
+	switch c.SSHStrategy {
+	case "", SSHStrategyIdentity, SSHStrategyAlias:
+		// Valid
+	default:
+		return fmt.Errorf("ssh strategy must be 'identity' or 'alias', got: %s", c.SSHStrategy)
+	}
+	if c.UsesSSHAlias() && c.Transport != "ssh" {
+		return fmt.Errorf("ssh strategy 'alias' requires ssh transport")
+	}
+
+	switch c.GitSigningFormat {
+	case "", "gpg", "ssh":
+		// Valid
+	default:
+		return fmt.Errorf("signing format must be 'gpg' or 'ssh', got: %s", c.GitSigningFormat)
+	}
+	if c.GitSigningFormat != "" && c.GitSigningKey == "" {
+		return fmt.Errorf("signing format requires a signing key")
+	}
+
+	return nil
+}
+
+// Load reads a context from a .ctx file. Files in an older format are
+// upgraded in place first (see migrate.go).
 func Load(name string) (*Context, error) {
 	path, err := ContextFile(name)
 	if err != nil {
 		return nil, err
 	}
 
-	file, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("context '%s' not found", name)
 		}
 		return nil, err
 	}
-	defer file.Close()
-
-	ctx := &Context{Name: name}
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			continue
-		}
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		switch key {
-		case "HOSTNAME":
-			ctx.Hostname = value
-		case "USER":
-			ctx.User = value
-		case "TRANSPORT":
-			ctx.Transport = value
-		case "SSH_KEY":
-			ctx.SSHKey = value
-		case "SSH_HOST_ALIAS":
-			// Legacy field - migrate to SSH_KEY if SSH_KEY not set
-			if ctx.SSHKey == "" {
-				ctx.SSHKey = value
-			}
+	if detectVersion(data) < FormatVersion {
+		if data, err = upgradeFile(path); err != nil {
+			return nil, err
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-
-	return ctx, nil
+	return decodeContext(path, name, data)
 }
 
-// Save writes a context to a .ctx file.
+// Save writes a context to a .ctx file in the current format.
 func (c *Context) Save() error {
 	path, err := ContextFile(c.Name)
 	if err != nil {
 		return err
 	}
 
-	file, err := os.Create(path)
+	data, err := encodeContext(c)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	fmt.Fprintf(file, "HOSTNAME=%s\n", c.Hostname)
-	fmt.Fprintf(file, "USER=%s\n", c.User)
-	fmt.Fprintf(file, "TRANSPORT=%s\n", c.Transport)
-	fmt.Fprintf(file, "SSH_KEY=%s\n", c.SSHKey)
+	lock, err := lockState()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
 
-	return nil
+	return fileutil.WriteFileAtomic(path, data, 0644)
 }
 
 // Exists checks if a context with the given name exists.
@@ -133,6 +162,12 @@ func Delete(name string) error {
 		return err
 	}
 
+	lock, err := lockState()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
 	if err := os.Remove(path); err != nil {
 		if os.IsNotExist(err) {
 			return fmt.Errorf("context '%s' not found", name)
@@ -143,7 +178,15 @@ func Delete(name string) error {
 	// Clear active pointer if it points to this context
 	active, _ := GetActive()
 	if active == name {
-		if err := ClearActive(); err != nil {
+		if err := clearActive(); err != nil {
+			return err
+		}
+	}
+
+	// Likewise the previous pointer, so "use -" can't return to it
+	previous, _ := GetPrevious()
+	if previous == name {
+		if err := clearPrevious(); err != nil {
 			return err
 		}
 	}
@@ -151,6 +194,51 @@ func Delete(name string) error {
 	return nil
 }
 
+// Copy saves a copy of context src under the name dst.
+func Copy(src, dst string) (*Context, error) {
+	if err := ValidateName(dst); err != nil {
+		return nil, err
+	}
+	exists, err := Exists(dst)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("context '%s' already exists", dst)
+	}
+
+	ctx, err := Load(src)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Name = dst
+	if err := ctx.Save(); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// Rename moves context oldName to newName, carrying the active and previous
+// pointers along with it.
+func Rename(oldName, newName string) (*Context, error) {
+	ctx, err := Copy(oldName, newName)
+	if err != nil {
+		return nil, err
+	}
+	if err := repointContext(oldName, newName); err != nil {
+		return nil, err
+	}
+	if err := Delete(oldName); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// HasGitIdentity reports whether the context carries any git identity settings.
+func (c *Context) HasGitIdentity() bool {
+	return c.GitName != "" || c.GitEmail != "" || c.GitSigningKey != ""
+}
+
 // String returns a human-readable representation of the context.
 func (c *Context) String() string {
 	s := fmt.Sprintf("%s@%s, %s", c.User, c.Hostname, c.Transport)
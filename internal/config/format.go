@@ -0,0 +1,198 @@
+// ABOUTME: Versioned YAML format for gh-context context files
+// ABOUTME: Strict decoding with line/key errors and ordered encoding of Context fields
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FormatVersion is the context file version written by Save. Version 1 is the
+// legacy KEY=VALUE format, upgraded in place by Load.
+const FormatVersion = 2
+
+// ValidationError points at the file, line and key of an invalid context file.
+type ValidationError struct {
+	File string
+	Line int    // 0 if the problem is not tied to a line
+	Key  string // Empty if the problem is not tied to a key
+	Msg  string
+}
+
+func (e *ValidationError) Error() string {
+	loc := e.File
+	if e.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	if e.Key != "" {
+		return fmt.Sprintf("%s: %s: %s", loc, e.Key, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", loc, e.Msg)
+}
+
+// fileField describes one key of the context file format.
+type fileField struct {
+	key      string
+	legacy   string   // KEY in version 1 files
+	required bool     // Must be present and non-empty
+	values   []string // Allowed values, if restricted
+	field    func(c *Context) *string
+}
+
+// fileFields lists the keys of a context file in the order Save writes them.
+var fileFields = []fileField{
+	{key: "hostname", legacy: "HOSTNAME", required: true, field: func(c *Context) *string { return &c.Hostname }},
+	{key: "user", legacy: "USER", required: true, field: func(c *Context) *string { return &c.User }},
+	{key: "transport", legacy: "TRANSPORT", required: true, values: []string{"ssh", "https"}, field: func(c *Context) *string { return &c.Transport }},
+	{key: "ssh_key", legacy: "SSH_KEY", field: func(c *Context) *string { return &c.SSHKey }},
+	{key: "ssh_strategy", legacy: "SSH_STRATEGY", values: []string{SSHStrategyIdentity, SSHStrategyAlias}, field: func(c *Context) *string { return &c.SSHStrategy }},
+	{key: "git_name", legacy: "GIT_NAME", field: func(c *Context) *string { return &c.GitName }},
+	{key: "git_email", legacy: "GIT_EMAIL", field: func(c *Context) *string { return &c.GitEmail }},
+	{key: "git_signing_key", legacy: "GIT_SIGNING_KEY", field: func(c *Context) *string { return &c.GitSigningKey }},
+	{key: "git_signing_format", legacy: "GIT_SIGNING_FORMAT", values: []string{"gpg", "ssh"}, field: func(c *Context) *string { return &c.GitSigningFormat }},
+}
+
+func lookupField(key string) *fileField {
+	for i := range fileFields {
+		if fileFields[i].key == key {
+			return &fileFields[i]
+		}
+	}
+	return nil
+}
+
+func lookupLegacyField(key string) *fileField {
+	for i := range fileFields {
+		if fileFields[i].legacy == key {
+			return &fileFields[i]
+		}
+	}
+	return nil
+}
+
+// checkValue returns a message if value is not allowed for f.
+func (f *fileField) checkValue(value string) string {
+	if value == "" || len(f.values) == 0 {
+		return ""
+	}
+	for _, v := range f.values {
+		if v == value {
+			return ""
+		}
+	}
+	return fmt.Sprintf("must be one of %s, got %q", strings.Join(f.values, ", "), value)
+}
+
+// checkRequired returns an error for the first required field ctx lacks.
+func checkRequired(path string, ctx *Context) error {
+	for _, f := range fileFields {
+		if f.required && *f.field(ctx) == "" {
+			return &ValidationError{File: path, Key: f.key, Msg: "required key is missing"}
+		}
+	}
+	return nil
+}
+
+// encodeContext renders a context in the current file format.
+func encodeContext(c *Context) ([]byte, error) {
+	doc := &yaml.Node{Kind: yaml.MappingNode, HeadComment: "gh-context context file"}
+	add := func(key, tag, value string) {
+		doc.Content = append(doc.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value})
+	}
+
+	add("version", "!!int", strconv.Itoa(FormatVersion))
+	for _, f := range fileFields {
+		value := *f.field(c)
+		if value == "" && !f.required {
+			continue // Optional keys are only written when set
+		}
+		add(f.key, "!!str", value)
+	}
+
+	return yaml.Marshal(doc)
+}
+
+// decodeContext parses a current-format context file. Unknown or duplicate
+// keys, non-string values and disallowed values are errors.
+func decodeContext(path, name string, data []byte) (*Context, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &ValidationError{File: path, Msg: strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, &ValidationError{File: path, Line: 1, Msg: "expected a mapping of keys to values"}
+	}
+
+	ctx := &Context{Name: name}
+	version := 0
+	seen := make(map[string]int)
+
+	mapping := doc.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		k, v := mapping.Content[i], mapping.Content[i+1]
+
+		if first, dup := seen[k.Value]; dup {
+			return nil, &ValidationError{File: path, Line: k.Line, Key: k.Value, Msg: fmt.Sprintf("duplicate key (first set on line %d)", first)}
+		}
+		seen[k.Value] = k.Line
+
+		if v.Kind != yaml.ScalarNode {
+			return nil, &ValidationError{File: path, Line: v.Line, Key: k.Value, Msg: "value must be a string"}
+		}
+
+		if k.Value == "version" {
+			n, err := strconv.Atoi(v.Value)
+			if err != nil {
+				return nil, &ValidationError{File: path, Line: v.Line, Key: k.Value, Msg: fmt.Sprintf("must be a number, got %q", v.Value)}
+			}
+			version = n
+			continue
+		}
+
+		f := lookupField(k.Value)
+		if f == nil {
+			return nil, &ValidationError{File: path, Line: k.Line, Key: k.Value, Msg: "unknown key"}
+		}
+		if msg := f.checkValue(v.Value); msg != "" {
+			return nil, &ValidationError{File: path, Line: v.Line, Key: k.Value, Msg: msg}
+		}
+		*f.field(ctx) = v.Value
+	}
+
+	if version != FormatVersion {
+		line := seen["version"]
+		if line == 0 {
+			return nil, &ValidationError{File: path, Key: "version", Msg: "required key is missing"}
+		}
+		return nil, &ValidationError{File: path, Line: line, Key: "version",
+			Msg: fmt.Sprintf("unsupported version %d (this gh-context reads version %d)", version, FormatVersion)}
+	}
+
+	if err := checkRequired(path, ctx); err != nil {
+		return nil, err
+	}
+
+	// Identity is the default strategy and is stored as empty in memory
+	if ctx.SSHStrategy == SSHStrategyIdentity {
+		ctx.SSHStrategy = ""
+	}
+
+	return ctx, nil
+}
+
+// Marshal renders the context in the context file format.
+func (c *Context) Marshal() ([]byte, error) {
+	return encodeContext(c)
+}
+
+// Parse decodes a context named name from data in the context file format.
+// source names the data in error messages.
+func Parse(source, name string, data []byte) (*Context, error) {
+	return decodeContext(source, name, data)
+}
@@ -0,0 +1,135 @@
+// ABOUTME: Git identity values saved before gh-context first overwrote them
+// ABOUTME: Lets switching away restore the user's own user.name, email and signing settings
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/peterjmorgan/gh-context/internal/fileutil"
+	"gopkg.in/yaml.v3"
+)
+
+// PriorIdentity is the git identity config of one scope (global, or the local
+// config of one repository) as it was before a context identity was applied.
+type PriorIdentity struct {
+	Scope  string            `yaml:"scope"`
+	Repo   string            `yaml:"repo,omitempty"` // Repository root, for local scope
+	Values map[string]string `yaml:"values"`         // Keys that were set; absent keys were unset
+}
+
+// priorIdentityDoc is the on-disk form of the saved identities.
+type priorIdentityDoc struct {
+	Version int             `yaml:"version"`
+	Saved   []PriorIdentity `yaml:"saved"`
+}
+
+// LoadPriorIdentity returns the values saved for scope and repo, or nil if
+// none were saved.
+func LoadPriorIdentity(scope, repo string) (map[string]string, error) {
+	path, err := PriorIdentityFile()
+	if err != nil {
+		return nil, err
+	}
+	doc, err := readPriorIdentities(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range doc.Saved {
+		if p.Scope == scope && p.Repo == repo {
+			if p.Values == nil {
+				return map[string]string{}, nil
+			}
+			return p.Values, nil
+		}
+	}
+	return nil, nil
+}
+
+// SavePriorIdentity records values for scope and repo unless some are already
+// saved: only the state before the first context identity is worth keeping.
+func SavePriorIdentity(scope, repo string, values map[string]string) error {
+	return updatePriorIdentities(func(doc *priorIdentityDoc) {
+		for _, p := range doc.Saved {
+			if p.Scope == scope && p.Repo == repo {
+				return
+			}
+		}
+		doc.Saved = append(doc.Saved, PriorIdentity{Scope: scope, Repo: repo, Values: values})
+	})
+}
+
+// ForgetPriorIdentity drops the values saved for scope and repo, once they
+// have been restored.
+func ForgetPriorIdentity(scope, repo string) error {
+	return updatePriorIdentities(func(doc *priorIdentityDoc) {
+		kept := doc.Saved[:0]
+		for _, p := range doc.Saved {
+			if p.Scope != scope || p.Repo != repo {
+				kept = append(kept, p)
+			}
+		}
+		doc.Saved = kept
+	})
+}
+
+// updatePriorIdentities applies fn to the saved identities under the state lock.
+func updatePriorIdentities(fn func(doc *priorIdentityDoc)) error {
+	path, err := PriorIdentityFile()
+	if err != nil {
+		return err
+	}
+
+	lock, err := lockState()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	doc, err := readPriorIdentities(path)
+	if err != nil {
+		return err
+	}
+	fn(doc)
+
+	if len(doc.Saved) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	return fileutil.WriteFileAtomic(path, buf.Bytes(), 0644)
+}
+
+func readPriorIdentities(path string) (*priorIdentityDoc, error) {
+	doc := &priorIdentityDoc{Version: 1}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return doc, nil
+		}
+		return nil, err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(doc); err != nil && len(bytes.TrimSpace(data)) > 0 {
+		return nil, fmt.Errorf("invalid saved git identity %s: %w", path, err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPriorIdentityKeepsFirstSave(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+
+	first := map[string]string{"user.name": "Own Name"}
+	if err := SavePriorIdentity("global", "", first); err != nil {
+		t.Fatal(err)
+	}
+	// A later apply must not replace the user's own values with a context's
+	if err := SavePriorIdentity("global", "", map[string]string{"user.name": "Work Name"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SavePriorIdentity("local", "/src/repo", map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadPriorIdentity("global", "")
+	if err != nil || !reflect.DeepEqual(got, first) {
+		t.Errorf("global = %v, %v; want %v", got, err, first)
+	}
+	if got, err := LoadPriorIdentity("local", "/src/repo"); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("local = %#v, %v; want an empty saved set", got, err)
+	}
+	if got, err := LoadPriorIdentity("local", "/src/other"); err != nil || got != nil {
+		t.Errorf("unsaved repo = %v, %v; want nil", got, err)
+	}
+
+	if err := ForgetPriorIdentity("global", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := LoadPriorIdentity("global", ""); got != nil {
+		t.Errorf("global after forget = %v, want nil", got)
+	}
+	if got, _ := LoadPriorIdentity("local", "/src/repo"); got == nil {
+		t.Error("forgetting global dropped the local entry")
+	}
+}
@@ -0,0 +1,98 @@
+// ABOUTME: Per-repository cache of context resolution for the shell hooks
+// ABOUTME: Entries are keyed by repo root and invalidated by a stamp of the inputs
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/peterjmorgan/gh-context/internal/fileutil"
+)
+
+// maxHookCacheEntries bounds the cache; the oldest entries are dropped first.
+const maxHookCacheEntries = 256
+
+// HookCache remembers which context a repository resolved to, so the shell
+// hooks don't re-read rules and remotes on every prompt. A missing or corrupt
+// cache file behaves like an empty cache.
+type HookCache struct {
+	path    string
+	Entries map[string]HookCacheEntry `json:"entries"`
+	dirty   bool
+}
+
+// HookCacheEntry is the cached resolution of one repository root.
+type HookCacheEntry struct {
+	Stamp   string `json:"stamp"` // Identifies the inputs the entry was computed from
+	Context string `json:"context"`
+	Stored  int64  `json:"stored"` // Unix time the entry was written
+}
+
+// LoadHookCache reads the hook cache.
+func LoadHookCache() *HookCache {
+	cache := &HookCache{Entries: make(map[string]HookCacheEntry)}
+
+	path, err := HookCacheFile()
+	if err != nil {
+		return cache
+	}
+	cache.path = path
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, cache); err != nil || cache.Entries == nil {
+		cache.Entries = make(map[string]HookCacheEntry)
+	}
+	return cache
+}
+
+// Lookup returns the cached context of root if it was stored with stamp.
+func (c *HookCache) Lookup(root, stamp string) (string, bool) {
+	entry, ok := c.Entries[root]
+	if !ok || entry.Stamp != stamp {
+		return "", false
+	}
+	return entry.Context, true
+}
+
+// Store records the context root resolved to.
+func (c *HookCache) Store(root, stamp, context string) {
+	c.Entries[root] = HookCacheEntry{Stamp: stamp, Context: context, Stored: time.Now().Unix()}
+	c.dirty = true
+}
+
+// Save writes the cache if it changed, dropping the oldest entries beyond
+// maxHookCacheEntries.
+func (c *HookCache) Save() error {
+	if !c.dirty || c.path == "" {
+		return nil
+	}
+
+	if len(c.Entries) > maxHookCacheEntries {
+		roots := make([]string, 0, len(c.Entries))
+		for root := range c.Entries {
+			roots = append(roots, root)
+		}
+		sort.Slice(roots, func(i, j int) bool {
+			return c.Entries[roots[i]].Stored > c.Entries[roots[j]].Stored
+		})
+		for _, root := range roots[maxHookCacheEntries:] {
+			delete(c.Entries, root)
+		}
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(c.path, data, 0644)
+}
@@ -0,0 +1,31 @@
+// ABOUTME: Cross-process locking for the gh-context contexts directory
+// ABOUTME: Guards context files and pointers against concurrent shell hooks
+
+package config
+
+import (
+	"path/filepath"
+
+	"github.com/peterjmorgan/gh-context/internal/fileutil"
+)
+
+// lockState takes the lock guarding writes to context files and pointers.
+// Held only for the duration of a single config operation.
+func lockState() (*fileutil.Lock, error) {
+	dir, err := ContextDir()
+	if err != nil {
+		return nil, err
+	}
+	return fileutil.LockFile(filepath.Join(dir, ".lock"))
+}
+
+// LockSwitch serializes whole context switches (SSH config, gh auth, git config
+// and the active pointer) across concurrent gh context processes.
+// It is separate from the state lock, so config functions can be called while held.
+func LockSwitch() (*fileutil.Lock, error) {
+	dir, err := ContextDir()
+	if err != nil {
+		return nil, err
+	}
+	return fileutil.LockFile(filepath.Join(dir, ".switch.lock"))
+}
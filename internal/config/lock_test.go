@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentSetActiveAndSave(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+
+	const workers, rounds = 8, 10
+	names := make(map[string]bool)
+	for w := 0; w < workers; w++ {
+		names[fmt.Sprintf("ctx-%d", w)] = true
+	}
+
+	activePath, err := ActiveFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		// The shell hook reads the pointer without the lock
+		defer readers.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			data, err := os.ReadFile(activePath)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				t.Errorf("read during writes: %v", err)
+				return
+			}
+			if name := strings.TrimSpace(string(data)); !names[name] {
+				t.Errorf("active pointer is corrupt: %q", data)
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			name := fmt.Sprintf("ctx-%d", w)
+			for r := 0; r < rounds; r++ {
+				ctx := &Context{
+					Name:      name,
+					Hostname:  "github.com",
+					User:      fmt.Sprintf("user-%d-%d", w, r),
+					Transport: "https",
+				}
+				if err := ctx.Save(); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := SetActive(name); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	for w := 0; w < workers; w++ {
+		name := fmt.Sprintf("ctx-%d", w)
+		ctx, err := Load(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if want := fmt.Sprintf("user-%d-%d", w, rounds-1); ctx.User != want {
+			t.Errorf("%s: user = %q, want %q (last save was lost)", name, ctx.User, want)
+		}
+	}
+
+	active, err := GetActive()
+	if err != nil || !names[active] {
+		t.Errorf("active = %q, %v; want one of the contexts", active, err)
+	}
+	previous, err := GetPrevious()
+	if err != nil || !names[previous] || previous == active {
+		t.Errorf("previous = %q, %v; want a context other than %q", previous, err, active)
+	}
+}
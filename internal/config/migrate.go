@@ -0,0 +1,167 @@
+// ABOUTME: Migration pipeline for gh-context context files
+// ABOUTME: Detects a file's version and upgrades it step by step, keeping a backup
+
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/peterjmorgan/gh-context/internal/fileutil"
+	"gopkg.in/yaml.v3"
+)
+
+// migration upgrades a context file from version from to from+1.
+type migration struct {
+	from    int
+	migrate func(path string, data []byte) ([]byte, error)
+}
+
+// migrations run in order; each one's output is the next one's input.
+var migrations = []migration{
+	{from: 1, migrate: migrateKeyValue},
+}
+
+// legacyLinePattern matches a KEY=VALUE line of a version 1 file.
+var legacyLinePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\s*=`)
+
+// detectVersion returns the format version of a context file. Files it can't
+// make sense of are reported as current so decoding produces the real error.
+func detectVersion(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if legacyLinePattern.MatchString(line) {
+			return 1
+		}
+		break
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return 1 // An empty legacy file
+	}
+
+	var header struct {
+		Version int `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil || header.Version == 0 {
+		return FormatVersion
+	}
+	return header.Version
+}
+
+// upgrade runs the migrations needed to bring data from version to FormatVersion.
+func upgrade(path string, data []byte, version int) ([]byte, error) {
+	for _, m := range migrations {
+		if m.from != version {
+			continue
+		}
+		var err error
+		if data, err = m.migrate(path, data); err != nil {
+			return nil, err
+		}
+		version++
+	}
+
+	if version != FormatVersion {
+		return nil, &ValidationError{File: path, Key: "version", Msg: fmt.Sprintf("cannot upgrade from version %d", version)}
+	}
+	return data, nil
+}
+
+// upgradeFile migrates a context file in place, saving the original next to it
+// as <name>.ctx.v<N>.bak. Returns the upgraded contents.
+func upgradeFile(path string) ([]byte, error) {
+	lock, err := lockState()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	// Re-read under the lock: another process may have upgraded it already
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	version := detectVersion(data)
+	if version >= FormatVersion {
+		return data, nil
+	}
+
+	upgraded, err := upgrade(path, data, version)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if err := fileutil.WriteFileAtomic(backup, data, 0644); err != nil {
+		return nil, fmt.Errorf("cannot back up %s before upgrading it: %w", path, err)
+	}
+	if err := fileutil.WriteFileAtomic(path, upgraded, 0644); err != nil {
+		return nil, err
+	}
+	return upgraded, nil
+}
+
+// migrateKeyValue converts a version 1 KEY=VALUE file to version 2.
+func migrateKeyValue(path string, data []byte) ([]byte, error) {
+	ctx := &Context{}
+	hostAlias := ""
+	seen := make(map[string]int)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, &ValidationError{File: path, Line: lineNum, Msg: fmt.Sprintf("expected KEY=VALUE, got %q", line)}
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		if first, dup := seen[key]; dup {
+			return nil, &ValidationError{File: path, Line: lineNum, Key: key, Msg: fmt.Sprintf("duplicate key (first set on line %d)", first)}
+		}
+		seen[key] = lineNum
+
+		// SSH_HOST_ALIAS predates SSH_KEY and held the key path
+		if key == "SSH_HOST_ALIAS" {
+			hostAlias = value
+			continue
+		}
+
+		f := lookupLegacyField(key)
+		if f == nil {
+			return nil, &ValidationError{File: path, Line: lineNum, Key: key, Msg: "unknown key"}
+		}
+		if msg := f.checkValue(value); msg != "" {
+			return nil, &ValidationError{File: path, Line: lineNum, Key: key, Msg: msg}
+		}
+		*f.field(ctx) = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if ctx.SSHKey == "" {
+		ctx.SSHKey = hostAlias
+	}
+
+	if err := checkRequired(path, ctx); err != nil {
+		return nil, err
+	}
+	return encodeContext(ctx)
+}
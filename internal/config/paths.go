@@ -44,3 +44,58 @@ func ActiveFile() (string, error) {
 	}
 	return filepath.Join(dir, "active"), nil
 }
+
+// ActiveScopeFile returns the path to the file recording where the active
+// context's git identity was written.
+func ActiveScopeFile() (string, error) {
+	dir, err := ContextDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "active-scope"), nil
+}
+
+// PreviousFile returns the path to the previous context pointer file.
+func PreviousFile() (string, error) {
+	dir, err := ContextDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "previous"), nil
+}
+
+// BindingsFile returns the path to the registry of bound repositories.
+func BindingsFile() (string, error) {
+	dir, err := ContextDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bindings.yml"), nil
+}
+
+// PriorIdentityFile returns the path to the git identity values saved before
+// a context identity was first applied.
+func PriorIdentityFile() (string, error) {
+	dir, err := ContextDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-identity.yml"), nil
+}
+
+// RulesFile returns the path to the auto-binding rules file.
+func RulesFile() (string, error) {
+	dir, err := ContextDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "rules.yml"), nil
+}
+
+// HookCacheFile returns the path to the shell hook's resolution cache. It lives
+// in gh's cache directory since it is safe to delete at any time. The
+// directory is created when the cache is saved, so prompts can read it
+// without writing anything.
+func HookCacheFile() (string, error) {
+	return filepath.Join(ghConfig.CacheDir(), "gh-context", "hook-cache.json"), nil
+}
@@ -0,0 +1,295 @@
+// ABOUTME: Auto-binding rules that pick a context for repos without .ghcontext
+// ABOUTME: Matches origin remotes (host/owner/repo) or repo directories against globs
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/peterjmorgan/gh-context/internal/fileutil"
+	"gopkg.in/yaml.v3"
+)
+
+// RulesVersion is the rules file version this gh-context reads.
+const RulesVersion = 1
+
+// Rule binds repositories matching a pattern to a context. Exactly one of
+// Remote and Dir is set.
+//
+// Patterns are /-separated globs: * and ? match within one segment and **
+// matches any number of segments. Remote patterns match host/owner/repo of
+// the origin remote, case-insensitively. Dir patterns match the repo root and
+// may start with ~/.
+type Rule struct {
+	Remote  string
+	Dir     string
+	Context string
+	Line    int // Line of the rule in the rules file
+}
+
+// String describes the rule as it appears in the rules file.
+func (r *Rule) String() string {
+	if r.Remote != "" {
+		return fmt.Sprintf("remote %s → %s", r.Remote, r.Context)
+	}
+	return fmt.Sprintf("dir %s → %s", r.Dir, r.Context)
+}
+
+// Matches reports whether a repo at root with the given origin remote
+// (host/owner/repo, or empty if unknown) matches the rule.
+func (r *Rule) Matches(remote, root string) bool {
+	if r.Remote != "" {
+		return remote != "" && matchGlob(strings.ToLower(r.Remote), strings.ToLower(remote))
+	}
+
+	if root == "" {
+		return false
+	}
+	subject := filepath.ToSlash(root)
+	if strings.HasPrefix(r.Dir, "~/") {
+		subject = HomeRelative(root)
+	}
+	return matchGlob(strings.TrimSuffix(filepath.ToSlash(r.Dir), "/"), subject)
+}
+
+// LoadRules reads the rules file. A missing file means no rules.
+func LoadRules() ([]Rule, error) {
+	path, err := RulesFile()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return ParseRules(path, data)
+}
+
+// MatchRule returns the first rule matching the repo at root, or nil.
+func MatchRule(rules []Rule, remote, root string) *Rule {
+	for i := range rules {
+		if rules[i].Matches(remote, root) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// ParseRules decodes a rules file. source names the data in error messages.
+func ParseRules(source string, data []byte) ([]Rule, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &ValidationError{File: source, Msg: strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil // Empty file
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, &ValidationError{File: source, Line: 1, Msg: "expected a mapping with version and rules"}
+	}
+
+	var rules []Rule
+	version, versionLine := 0, 0
+	mapping := doc.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		k, v := mapping.Content[i], mapping.Content[i+1]
+		switch k.Value {
+		case "version":
+			n, err := strconv.Atoi(v.Value)
+			if err != nil || v.Kind != yaml.ScalarNode {
+				return nil, &ValidationError{File: source, Line: v.Line, Key: k.Value, Msg: fmt.Sprintf("must be a number, got %q", v.Value)}
+			}
+			version, versionLine = n, v.Line
+		case "rules":
+			if v.Kind != yaml.SequenceNode {
+				return nil, &ValidationError{File: source, Line: v.Line, Key: k.Value, Msg: "must be a list of rules"}
+			}
+			for _, item := range v.Content {
+				rule, err := decodeRule(source, item)
+				if err != nil {
+					return nil, err
+				}
+				rules = append(rules, rule)
+			}
+		default:
+			return nil, &ValidationError{File: source, Line: k.Line, Key: k.Value, Msg: "unknown key"}
+		}
+	}
+
+	if versionLine == 0 {
+		return nil, &ValidationError{File: source, Key: "version", Msg: "required key is missing"}
+	}
+	if version != RulesVersion {
+		return nil, &ValidationError{File: source, Line: versionLine, Key: "version",
+			Msg: fmt.Sprintf("unsupported version %d (this gh-context reads version %d)", version, RulesVersion)}
+	}
+	return rules, nil
+}
+
+// decodeRule decodes one entry of the rules list.
+func decodeRule(source string, node *yaml.Node) (Rule, error) {
+	rule := Rule{Line: node.Line}
+	if node.Kind != yaml.MappingNode {
+		return rule, &ValidationError{File: source, Line: node.Line, Msg: "rule must be a mapping with remote or dir, and context"}
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		if v.Kind != yaml.ScalarNode {
+			return rule, &ValidationError{File: source, Line: v.Line, Key: k.Value, Msg: "value must be a string"}
+		}
+		switch k.Value {
+		case "remote":
+			rule.Remote = v.Value
+		case "dir":
+			rule.Dir = v.Value
+		case "context":
+			rule.Context = v.Value
+		default:
+			return rule, &ValidationError{File: source, Line: k.Line, Key: k.Value, Msg: "unknown key"}
+		}
+	}
+
+	switch {
+	case rule.Remote == "" && rule.Dir == "":
+		return rule, &ValidationError{File: source, Line: rule.Line, Msg: "rule needs a remote or dir pattern"}
+	case rule.Remote != "" && rule.Dir != "":
+		return rule, &ValidationError{File: source, Line: rule.Line, Msg: "rule has both remote and dir; use one rule for each"}
+	case rule.Context == "":
+		return rule, &ValidationError{File: source, Line: rule.Line, Key: "context", Msg: "required key is missing"}
+	}
+
+	pattern := rule.Remote + rule.Dir
+	if _, err := path.Match(pattern, ""); err != nil {
+		return rule, &ValidationError{File: source, Line: rule.Line, Msg: fmt.Sprintf("invalid pattern %q", pattern)}
+	}
+	return rule, nil
+}
+
+// RenameRuleContext points the rules that name oldName at newName and returns
+// how many were changed. Only the context values are rewritten, so the rest of
+// the file, comments included, stays as the user wrote it.
+func RenameRuleContext(oldName, newName string) (int, error) {
+	path, err := RulesFile()
+	if err != nil {
+		return 0, err
+	}
+
+	lock, err := lockState()
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	out, n, err := renameRuleContext(path, data, oldName, newName)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	if err := fileutil.WriteFileAtomic(path, out, 0644); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// renameRuleContext rewrites the context values equal to oldName in a rules
+// file, keeping each value's quoting style.
+func renameRuleContext(source string, data []byte, oldName, newName string) ([]byte, int, error) {
+	// A file that doesn't parse is left for the user to fix
+	if _, err := ParseRules(source, data); err != nil {
+		return nil, 0, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return data, 0, err
+	}
+
+	var values []*yaml.Node
+	mapping := doc.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != "rules" {
+			continue
+		}
+		for _, rule := range mapping.Content[i+1].Content {
+			for j := 0; j+1 < len(rule.Content); j += 2 {
+				if rule.Content[j].Value == "context" && rule.Content[j+1].Value == oldName {
+					values = append(values, rule.Content[j+1])
+				}
+			}
+		}
+	}
+
+	// Replace from the end so earlier positions on a line stay valid
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Line != values[j].Line {
+			return values[i].Line > values[j].Line
+		}
+		return values[i].Column > values[j].Column
+	})
+
+	lines := strings.SplitAfter(string(data), "\n")
+	for _, v := range values {
+		quote := ""
+		switch v.Style {
+		case yaml.SingleQuotedStyle:
+			quote = "'"
+		case yaml.DoubleQuotedStyle:
+			quote = `"`
+		}
+		line := []rune(lines[v.Line-1])
+		start := v.Column - 1
+		old := quote + oldName + quote
+		if start < 0 || start > len(line) || !strings.HasPrefix(string(line[start:]), old) {
+			return nil, 0, &ValidationError{File: source, Line: v.Line, Key: "context", Msg: "cannot rewrite this value"}
+		}
+		lines[v.Line-1] = string(line[:start]) + quote + newName + quote + string(line[start+len([]rune(old)):])
+	}
+	return []byte(strings.Join(lines, "")), len(values), nil
+}
+
+// matchGlob matches a /-separated subject against a pattern where ** spans
+// any number of segments.
+func matchGlob(pattern, subject string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(subject, "/"))
+}
+
+func matchSegments(pattern, subject []string) bool {
+	if len(pattern) == 0 {
+		return len(subject) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(subject); i++ {
+			if matchSegments(pattern[1:], subject[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(subject) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], subject[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], subject[1:])
+}
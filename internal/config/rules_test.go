@@ -0,0 +1,204 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		subject string
+		want    bool
+	}{
+		{"github.com/acme/app", "github.com/acme/app", true},
+		{"github.com/acme/app", "github.com/acme/other", false},
+		{"github.com/acme/*", "github.com/acme/app", true},
+		{"github.com/acme/*", "github.com/acme", false},
+		{"github.com/acme/*", "github.com/acme/app/extra", false},
+		{"github.com/*/app", "github.com/acme/app", true},
+		{"github.com/acme-*/*", "github.com/acme-labs/app", true},
+		{"github.com/acme?/*", "github.com/acme2/app", true},
+		{"github.com/acme?/*", "github.com/acme/app", false},
+		{"*", "github.com/acme/app", false}, // * stays within one segment
+		{"**", "github.com/acme/app", true},
+		{"**", "", true},
+		{"github.com/**", "github.com/acme/app", true},
+		{"github.com/**", "github.com", true}, // ** may match no segments
+		{"**/app", "github.com/acme/app", true},
+		{"**/app", "github.com/acme/other", false},
+		{"github.com/**/app", "github.com/app", true},
+		{"github.com/**/app", "github.com/acme/app", true},
+		{"github.com/**/app", "github.com/a/b/c/app", true},
+		{"github.com/**/app", "gitlab.com/acme/app", false},
+		{"**/acme/**", "github.com/acme/app", true},
+		{"**/acme/**", "github.com/acmecorp/app", false},
+		{"/home/me/work/**", "/home/me/work/client/app", true},
+		{"/home/me/work/**", "/home/me/personal/app", false},
+		{"~/work/*", "~/work/app", true},
+		{"[", "[", false}, // Malformed segments never match
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.subject, func(t *testing.T) {
+			if got := matchGlob(tt.pattern, tt.subject); got != tt.want {
+				t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.subject, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		name   string
+		rule   Rule
+		remote string
+		root   string
+		want   bool
+	}{
+		{"remote", Rule{Remote: "github.com/acme/*"}, "github.com/acme/app", "/src/app", true},
+		{"remote is case-insensitive", Rule{Remote: "GitHub.com/ACME/*"}, "github.com/acme/App", "/src/app", true},
+		{"remote rule without a remote", Rule{Remote: "**"}, "", "/src/app", false},
+		{"remote rule ignores the directory", Rule{Remote: "github.com/acme/*"}, "github.com/other/app", "/src/acme/app", false},
+		{"absolute dir", Rule{Dir: "/src/work/**"}, "", "/src/work/client/app", true},
+		{"dir with trailing slash", Rule{Dir: "/src/work/*/"}, "", "/src/work/app", true},
+		{"dir rule ignores the remote", Rule{Dir: "/src/work/*"}, "github.com/acme/app", "/src/personal/app", false},
+		{"home-relative dir", Rule{Dir: "~/work/**"}, "", filepath.Join(home, "work", "acme", "app"), true},
+		{"home-relative dir elsewhere", Rule{Dir: "~/work/**"}, "", filepath.Join(home, "personal", "app"), false},
+		{"dir rule without a root", Rule{Dir: "**"}, "github.com/acme/app", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Matches(tt.remote, tt.root); got != tt.want {
+				t.Errorf("%s Matches(%q, %q) = %v, want %v", tt.rule.String(), tt.remote, tt.root, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchRuleFirstWins(t *testing.T) {
+	rules, err := ParseRules("rules.yml", []byte(`version: 1
+rules:
+  - remote: github.com/acme/secret-*
+    context: acme-admin
+  - remote: github.com/acme/**
+    context: acme
+  - dir: /src/**
+    context: personal
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{"github.com/acme/secret-app", "acme-admin"},
+		{"github.com/acme/app", "acme"},
+		{"github.com/me/app", "personal"},
+	}
+	for _, tt := range tests {
+		rule := MatchRule(rules, tt.remote, "/src/app")
+		if rule == nil || rule.Context != tt.want {
+			t.Errorf("MatchRule(%q) = %v, want %s", tt.remote, rule, tt.want)
+		}
+	}
+	if rule := MatchRule(rules, "", "/elsewhere/app"); rule != nil {
+		t.Errorf("MatchRule outside every pattern = %v, want nil", rule)
+	}
+}
+
+func TestRenameRuleContext(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+		n    int
+	}{
+		{
+			name: "block style with comments",
+			data: "version: 1\n# Work repos\nrules:\n  - remote: github.com/acme/*   # all of acme\n    context: work # main account\n  - dir: ~/src/**\n    context: personal\n",
+			want: "version: 1\n# Work repos\nrules:\n  - remote: github.com/acme/*   # all of acme\n    context: acme # main account\n  - dir: ~/src/**\n    context: personal\n",
+			n:    1,
+		},
+		{
+			name: "quoted values keep their quotes",
+			data: "version: 1\nrules:\n  - remote: a/*\n    context: 'work'\n  - remote: b/*\n    context: \"work\"\n",
+			want: "version: 1\nrules:\n  - remote: a/*\n    context: 'acme'\n  - remote: b/*\n    context: \"acme\"\n",
+			n:    2,
+		},
+		{
+			name: "flow style, several on a line",
+			data: "version: 1\nrules: [{remote: ünï/*, context: work}, {dir: /x/**, context: work}]\n",
+			want: "version: 1\nrules: [{remote: ünï/*, context: acme}, {dir: /x/**, context: acme}]\n",
+			n:    2,
+		},
+		{
+			name: "other values and keys untouched",
+			data: "version: 1\nrules:\n  - remote: github.com/work/*\n    context: work-2\n",
+			want: "version: 1\nrules:\n  - remote: github.com/work/*\n    context: work-2\n",
+			n:    0,
+		},
+		{
+			name: "crlf and no final newline",
+			data: "version: 1\r\nrules:\r\n  - dir: /src/**\r\n    context: work",
+			want: "version: 1\r\nrules:\r\n  - dir: /src/**\r\n    context: acme",
+			n:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, n, err := renameRuleContext("rules.yml", []byte(tt.data), "work", "acme")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.n {
+				t.Errorf("changed %d rules, want %d", n, tt.n)
+			}
+			if n > 0 && string(out) != tt.want {
+				t.Errorf("rewritten file:\n%q\nwant:\n%q", out, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := renameRuleContext("rules.yml", []byte("version: 1\nrules:\n  - context: work\n"), "work", "acme"); err == nil {
+		t.Error("invalid rules file was rewritten")
+	}
+}
+
+func TestRenameRuleContextFile(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+
+	if n, err := RenameRuleContext("work", "acme"); err != nil || n != 0 {
+		t.Fatalf("without a rules file: %d, %v; want 0, nil", n, err)
+	}
+
+	path, err := RulesFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("version: 1\nrules:\n  - remote: github.com/acme/*\n    context: work\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := RenameRuleContext("work", "acme"); err != nil || n != 1 {
+		t.Fatalf("RenameRuleContext = %d, %v; want 1, nil", n, err)
+	}
+	rules, err := LoadRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule := MatchRule(rules, "github.com/acme/app", ""); rule == nil || rule.Context != "acme" {
+		t.Errorf("rule after rename = %v, want context acme", rule)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/peterjmorgan/gh-context/internal/fileutil"
 )
 
 // List returns all saved context names.
@@ -39,22 +41,32 @@ func List() ([]string, error) {
 }
 
 // ListContexts returns all saved contexts with their full configuration.
+// Contexts that fail to load are skipped; use LoadAll to see why.
 func ListContexts() ([]*Context, error) {
+	contexts, _, err := LoadAll()
+	return contexts, err
+}
+
+// LoadAll loads every saved context, returning the ones that loaded and the
+// load errors of the ones that didn't.
+func LoadAll() ([]*Context, []error, error) {
 	names, err := List()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var contexts []*Context
+	var loadErrs []error
 	for _, name := range names {
 		ctx, err := Load(name)
 		if err != nil {
-			continue // Skip contexts that fail to load
+			loadErrs = append(loadErrs, err)
+			continue
 		}
 		contexts = append(contexts, ctx)
 	}
 
-	return contexts, nil
+	return contexts, loadErrs, nil
 }
 
 // GetActive returns the name of the currently active context.
@@ -64,7 +76,21 @@ func GetActive() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return readPointer(path)
+}
+
+// GetPrevious returns the name of the context that was active before the
+// current one. Returns empty string if there is none.
+func GetPrevious() (string, error) {
+	path, err := PreviousFile()
+	if err != nil {
+		return "", err
+	}
+	return readPointer(path)
+}
 
+// readPointer reads a context name from a pointer file.
+func readPointer(path string) (string, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -76,12 +102,17 @@ func GetActive() (string, error) {
 	return strings.TrimSpace(string(data)), nil
 }
 
-// SetActive sets the active context pointer.
+// SetActive sets the active context pointer. The outgoing context, if any
+// and different, becomes the previous context.
 func SetActive(name string) error {
 	path, err := ActiveFile()
 	if err != nil {
 		return err
 	}
+	previousPath, err := PreviousFile()
+	if err != nil {
+		return err
+	}
 
 	// Ensure parent directory exists
 	dir := filepath.Dir(path)
@@ -89,12 +120,121 @@ func SetActive(name string) error {
 		return err
 	}
 
-	return os.WriteFile(path, []byte(name+"\n"), 0644)
+	lock, err := lockState()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	outgoing, _ := readPointer(path)
+	if outgoing != "" && outgoing != name {
+		if err := fileutil.WriteFileAtomic(previousPath, []byte(outgoing+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+
+	return fileutil.WriteFileAtomic(path, []byte(name+"\n"), 0644)
+}
+
+// ActiveScope records which git config the active context's identity was
+// written to: "global", or "local" with the repository root.
+type ActiveScope struct {
+	Scope string
+	Repo  string
+}
+
+// GetActiveScope returns where the active context's identity was written.
+// The zero value means it was never recorded.
+func GetActiveScope() (ActiveScope, error) {
+	path, err := ActiveScopeFile()
+	if err != nil {
+		return ActiveScope{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ActiveScope{}, nil
+		}
+		return ActiveScope{}, err
+	}
+
+	scope, repo, _ := strings.Cut(strings.TrimRight(string(data), "\n"), "\n")
+	return ActiveScope{Scope: scope, Repo: repo}, nil
+}
+
+// SetActiveScope records where the active context's identity was written.
+// Call it after SetActive.
+func SetActiveScope(scope ActiveScope) error {
+	path, err := ActiveScopeFile()
+	if err != nil {
+		return err
+	}
+
+	lock, err := lockState()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	data := scope.Scope + "\n"
+	if scope.Repo != "" {
+		data += scope.Repo + "\n"
+	}
+	return fileutil.WriteFileAtomic(path, []byte(data), 0644)
+}
+
+// repointContext makes the active and previous pointers that name oldName
+// name newName instead.
+func repointContext(oldName, newName string) error {
+	lock, err := lockState()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	for _, pointer := range []func() (string, error){ActiveFile, PreviousFile} {
+		path, err := pointer()
+		if err != nil {
+			return err
+		}
+		if current, _ := readPointer(path); current == oldName {
+			if err := fileutil.WriteFileAtomic(path, []byte(newName+"\n"), 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 // ClearActive removes the active context pointer.
 func ClearActive() error {
-	path, err := ActiveFile()
+	lock, err := lockState()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	return clearActive()
+}
+
+// clearActive removes the active context pointer and its recorded scope; the
+// caller holds the state lock.
+func clearActive() error {
+	for _, file := range []func() (string, error){ActiveFile, ActiveScopeFile} {
+		path, err := file()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearPrevious removes the previous context pointer; the caller holds the state lock.
+func clearPrevious() error {
+	path, err := PreviousFile()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,35 @@
+package config
+
+import "testing"
+
+func TestActiveScope(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+
+	if got, err := GetActiveScope(); err != nil || got != (ActiveScope{}) {
+		t.Fatalf("unrecorded scope = %+v, %v; want zero value", got, err)
+	}
+
+	ctx := &Context{Name: "work", Hostname: "github.com", User: "work-user", Transport: "https"}
+	if err := ctx.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetActive("work"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []ActiveScope{{Scope: "local", Repo: "/src/app"}, {Scope: "global"}} {
+		if err := SetActiveScope(want); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := GetActiveScope(); err != nil || got != want {
+			t.Errorf("GetActiveScope = %+v, %v; want %+v", got, err, want)
+		}
+	}
+
+	// Deleting the active context forgets where its identity went
+	if err := Delete("work"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetActiveScope(); got != (ActiveScope{}) {
+		t.Errorf("scope after deleting the active context = %+v, want zero value", got)
+	}
+}
@@ -0,0 +1,148 @@
+// ABOUTME: Line-based unified diff for gh-context
+// ABOUTME: Renders changes between config versions the way diff -u does
+
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// op is a single edit in a line diff.
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Unified returns a unified diff turning a into b, with the given number of
+// context lines around each change. Returns "" if the inputs are equal.
+func Unified(aName, bName string, a, b []string, context int) string {
+	ops := lineOps(a, b)
+
+	changed := false
+	for _, o := range ops {
+		if o.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+
+	for start := 0; start < len(ops); {
+		// Find the next change
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+
+		// Extend the hunk while changes are within 2*context lines of each other
+		hunkStart := max(first-context, start)
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*context {
+				break
+			}
+		}
+		hunkEnd := min(last+context+1, len(ops))
+
+		writeHunk(&sb, ops, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+
+	return sb.String()
+}
+
+// writeHunk writes ops[from:to] with an @@ header.
+func writeHunk(sb *strings.Builder, ops []op, from, to int) {
+	// Line numbers of the hunk start in a and b (1-indexed)
+	aLine, bLine := 1, 1
+	for _, o := range ops[:from] {
+		if o.kind != '+' {
+			aLine++
+		}
+		if o.kind != '-' {
+			bLine++
+		}
+	}
+
+	aCount, bCount := 0, 0
+	for _, o := range ops[from:to] {
+		if o.kind != '+' {
+			aCount++
+		}
+		if o.kind != '-' {
+			bCount++
+		}
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+	for _, o := range ops[from:to] {
+		sb.WriteByte(o.kind)
+		sb.WriteString(o.line)
+		sb.WriteByte('\n')
+	}
+}
+
+// hunkRange formats a hunk range; empty ranges point at the line before.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// lineOps computes an edit script from a to b using a longest common subsequence.
+// Config files are small, so the quadratic table is fine.
+func lineOps(a, b []string) []op {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]op, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, op{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, op{'+', b[j]})
+	}
+	return ops
+}
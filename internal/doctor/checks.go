@@ -0,0 +1,297 @@
+// ABOUTME: Individual doctor checks for gh-context
+// ABOUTME: Each check returns a Result with a stable ID, severity and fix hint
+
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/peterjmorgan/gh-context/internal/auth"
+	"github.com/peterjmorgan/gh-context/internal/config"
+	"github.com/peterjmorgan/gh-context/internal/git"
+	"github.com/peterjmorgan/gh-context/internal/ssh"
+)
+
+func pass(id, context string, sev Severity, format string, a ...interface{}) Result {
+	return Result{ID: id, Context: context, Severity: sev, Status: StatusPass, Message: fmt.Sprintf(format, a...)}
+}
+
+func fail(id, context string, sev Severity, hint, format string, a ...interface{}) Result {
+	return Result{ID: id, Context: context, Severity: sev, Status: StatusFail, Message: fmt.Sprintf(format, a...), Hint: hint}
+}
+
+func skip(id, context string, sev Severity, format string, a ...interface{}) Result {
+	return Result{ID: id, Context: context, Severity: sev, Status: StatusSkip, Message: fmt.Sprintf(format, a...)}
+}
+
+// selectContexts returns the named contexts, or all of them if names is empty.
+func selectContexts(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return config.List()
+	}
+
+	for _, name := range names {
+		exists, err := config.Exists(name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("context '%s' not found", name)
+		}
+	}
+	return names, nil
+}
+
+// checkContextFile loads a context, returning nil if its file is invalid.
+func checkContextFile(name string) (*config.Context, Result) {
+	ctx, err := config.Load(name)
+	if err != nil {
+		return nil, fail(CheckContextFile, name, SeverityError,
+			"Fix the file by hand, or recreate the context with: gh context new",
+			"%v", err)
+	}
+	return ctx, pass(CheckContextFile, name, SeverityError, "Context file is valid")
+}
+
+func checkActivePointer() Result {
+	active, err := config.GetActive()
+	if err != nil {
+		return fail(CheckActivePointer, "", SeverityError, "", "Cannot read active context pointer: %v", err)
+	}
+	if active == "" {
+		return pass(CheckActivePointer, "", SeverityError, "No active context set")
+	}
+
+	exists, err := config.Exists(active)
+	if err != nil || !exists {
+		return fail(CheckActivePointer, active, SeverityError,
+			"Switch to an existing context with: gh context use <name>",
+			"Active context '%s' does not exist", active)
+	}
+	return pass(CheckActivePointer, active, SeverityError, "Active context '%s' exists", active)
+}
+
+func checkRepoBinding() Result {
+	bindingPath, err := git.BindingPath()
+	if err != nil || bindingPath == "" {
+		return skip(CheckRepoBinding, "", SeverityError, "Not inside a Git repository")
+	}
+
+	binding, err := git.GetBinding()
+	if err != nil {
+		return fail(CheckRepoBinding, "", SeverityError, "", "Cannot read %s: %v", bindingPath, err)
+	}
+	if binding == "" {
+		return skip(CheckRepoBinding, "", SeverityError, "Repository has no .ghcontext binding")
+	}
+
+	exists, err := config.Exists(binding)
+	if err != nil || !exists {
+		return fail(CheckRepoBinding, binding, SeverityError,
+			"Rebind with: gh context bind <name>  (or remove it: gh context unbind)",
+			"%s points to missing context '%s'", bindingPath, binding)
+	}
+	return pass(CheckRepoBinding, binding, SeverityError, "%s points to existing context '%s'", bindingPath, binding)
+}
+
+func checkRules() Result {
+	rulesPath, err := config.RulesFile()
+	if err != nil {
+		return fail(CheckRules, "", SeverityError, "", "Cannot locate rules file: %v", err)
+	}
+
+	rules, err := config.LoadRules()
+	if err != nil {
+		return fail(CheckRules, "", SeverityError, "Fix the rules file by hand (see 'gh context resolve --help')", "%v", err)
+	}
+	if len(rules) == 0 {
+		return skip(CheckRules, "", SeverityError, "No auto-binding rules")
+	}
+
+	for _, rule := range rules {
+		exists, err := config.Exists(rule.Context)
+		if err != nil || !exists {
+			return fail(CheckRules, rule.Context, SeverityError,
+				"Create the context or fix the rule",
+				"%s:%d: rule points to missing context '%s'", rulesPath, rule.Line, rule.Context)
+		}
+	}
+	return pass(CheckRules, "", SeverityError, "%d auto-binding rule(s) point to existing contexts", len(rules))
+}
+
+// contextChecks runs every per-context check.
+func contextChecks(ctx *config.Context, opts Options) []Result {
+	var results []Result
+
+	if ctx.Transport == "ssh" {
+		results = append(results, sshChecks(ctx, opts)...)
+	}
+	results = append(results, checkGHToken(ctx))
+
+	return results
+}
+
+func sshChecks(ctx *config.Context, opts Options) []Result {
+	name := ctx.Name
+	if ctx.SSHKey == "" {
+		return []Result{fail(CheckSSHKeyExists, name, SeverityError,
+			"Recreate the context with --ssh-key PATH",
+			"No SSH key configured for ssh transport")}
+	}
+
+	keyPath := ssh.ExpandPath(ctx.SSHKey)
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		return []Result{fail(CheckSSHKeyExists, name, SeverityError,
+			fmt.Sprintf("Create it with: ssh-keygen -t ed25519 -f %s", ctx.SSHKey),
+			"SSH key %s not found", ctx.SSHKey)}
+	}
+
+	results := []Result{pass(CheckSSHKeyExists, name, SeverityError, "SSH key %s exists", ctx.SSHKey)}
+	results = append(results, checkKeyPermissions(name, ctx.SSHKey, info))
+	results = append(results, checkPublicKey(name, ctx.SSHKey))
+	results = append(results, checkAgent(name, ctx.SSHKey))
+	results = append(results, checkSSHConfig(ctx))
+	if opts.VerifySSH {
+		results = append(results, checkSSHIdentity(ctx))
+	}
+	return results
+}
+
+func checkKeyPermissions(name, key string, info os.FileInfo) Result {
+	if runtime.GOOS == "windows" {
+		return skip(CheckSSHKeyPerms, name, SeverityWarning, "File permissions are not checked on Windows")
+	}
+
+	perm := info.Mode().Perm()
+	if perm&0077 != 0 {
+		return fail(CheckSSHKeyPerms, name, SeverityWarning,
+			fmt.Sprintf("Restrict it with: chmod 600 %s", key),
+			"SSH key %s has mode %04o; ssh refuses keys readable by others", key, perm)
+	}
+	return pass(CheckSSHKeyPerms, name, SeverityWarning, "SSH key %s has mode %04o", key, perm)
+}
+
+func checkPublicKey(name, key string) Result {
+	pub := ssh.PublicKeyPath(key)
+	if _, err := os.Stat(pub); err != nil {
+		return fail(CheckSSHPublicKey, name, SeverityWarning,
+			fmt.Sprintf("Regenerate it with: ssh-keygen -y -f %s > %s", key, pub),
+			"Public key %s not found", pub)
+	}
+	return pass(CheckSSHPublicKey, name, SeverityWarning, "Public key %s exists", pub)
+}
+
+func checkAgent(name, key string) Result {
+	loaded, err := ssh.AgentHasKey(key)
+	if errors.Is(err, ssh.ErrNoAgent) {
+		return skip(CheckSSHAgent, name, SeverityInfo, "No ssh-agent running")
+	}
+	if err != nil {
+		return skip(CheckSSHAgent, name, SeverityInfo, "Cannot check ssh-agent: %v", err)
+	}
+	if !loaded {
+		return fail(CheckSSHAgent, name, SeverityInfo,
+			fmt.Sprintf("Load it with: ssh-add %s", key),
+			"SSH key %s is not loaded in ssh-agent", key)
+	}
+	return pass(CheckSSHAgent, name, SeverityInfo, "SSH key %s is loaded in ssh-agent", key)
+}
+
+func checkSSHConfig(ctx *config.Context) Result {
+	name := ctx.Name
+	sshCfg, err := ssh.ParseConfig("")
+	if err != nil {
+		return fail(CheckSSHConfigMatch, name, SeverityError, "", "Cannot read SSH config: %v", err)
+	}
+
+	if ctx.UsesSSHAlias() {
+		alias := ssh.AliasName(ctx.Hostname, ctx.Name)
+		if sshCfg.ManagedStart >= 0 && sshCfg.ManagedEnd < 0 {
+			return fail(CheckSSHConfigMatch, name, SeverityError,
+				"Put the '# END gh-context' line back after the gh-context Host aliases",
+				"The gh-context section of ~/.ssh/config (line %d) has no end marker", sshCfg.ManagedStart+1)
+		}
+		a := sshCfg.FindAlias(alias)
+		if a == nil {
+			return fail(CheckSSHConfigMatch, name, SeverityError,
+				fmt.Sprintf("Recreate it with: gh context use %s", name),
+				"Managed alias 'Host %s' is missing from ~/.ssh/config", alias)
+		}
+		if ssh.ExpandPath(a.IdentityFile) != ssh.ExpandPath(ctx.SSHKey) {
+			return fail(CheckSSHConfigMatch, name, SeverityError,
+				fmt.Sprintf("Refresh it with: gh context use %s", name),
+				"Managed alias 'Host %s' uses %s instead of %s", alias, a.IdentityFile, ctx.SSHKey)
+		}
+		return pass(CheckSSHConfigMatch, name, SeverityError, "Managed alias 'Host %s' uses %s", alias, ctx.SSHKey)
+	}
+
+	block := sshCfg.FindHostBlock(ctx.Hostname)
+	if block == nil {
+		return fail(CheckSSHConfigMatch, name, SeverityError,
+			fmt.Sprintf("Add a 'Host %s' block with 'IdentityFile %s' to ~/.ssh/config", ctx.Hostname, ctx.SSHKey),
+			"No Host block in ~/.ssh/config matches %s", ctx.Hostname)
+	}
+
+	listed := false
+	for _, ifl := range block.IdentityFiles {
+		if ssh.ExpandPath(ifl.Path) == ssh.ExpandPath(ctx.SSHKey) {
+			listed = true
+			break
+		}
+	}
+	if !listed {
+		return fail(CheckSSHConfigMatch, name, SeverityError,
+			fmt.Sprintf("Add 'IdentityFile %s' (commented out) to the 'Host %s' block", ctx.SSHKey, block.Hostname),
+			"SSH key %s is not listed in the 'Host %s' block", ctx.SSHKey, block.Hostname)
+	}
+
+	// For the active context, ssh must actually offer this key first
+	active, _ := config.GetActive()
+	if active == name {
+		effective := sshCfg.GetActiveIdentityFile(ctx.Hostname)
+		if effective == "" {
+			return fail(CheckSSHConfigMatch, name, SeverityError,
+				fmt.Sprintf("Reapply with: gh context use %s", name),
+				"No IdentityFile is active for %s; ssh will only try its default keys", ctx.Hostname)
+		}
+		if ssh.ExpandPath(effective) != ssh.ExpandPath(ctx.SSHKey) {
+			return fail(CheckSSHConfigMatch, name, SeverityError,
+				fmt.Sprintf("Reapply with: gh context use %s", name),
+				"ssh offers %q first for %s, not %s", effective, ctx.Hostname, ctx.SSHKey)
+		}
+	}
+
+	return pass(CheckSSHConfigMatch, name, SeverityError, "'Host %s' block lists %s", block.Hostname, ctx.SSHKey)
+}
+
+func checkSSHIdentity(ctx *config.Context) Result {
+	login, err := ssh.VerifyIdentity(ctx.Hostname, ctx.SSHKey)
+	if errors.Is(err, ssh.ErrKeyRejected) {
+		return fail(CheckSSHIdentity, ctx.Name, SeverityError,
+			fmt.Sprintf("Add %s to the %s account at https://%s/settings/keys", ssh.PublicKeyPath(ctx.SSHKey), ctx.User, ctx.Hostname),
+			"%v", err)
+	}
+	if err != nil {
+		return fail(CheckSSHIdentity, ctx.Name, SeverityError, "", "%v", err)
+	}
+	if !strings.EqualFold(login, ctx.User) {
+		return fail(CheckSSHIdentity, ctx.Name, SeverityError,
+			fmt.Sprintf("Use a key registered to %s, or remove %s from the %s account", ctx.User, ctx.SSHKey, login),
+			"SSH key %s authenticates as '%s', expected '%s'", ctx.SSHKey, login, ctx.User)
+	}
+	return pass(CheckSSHIdentity, ctx.Name, SeverityError, "SSH key %s authenticates as '%s'", ctx.SSHKey, login)
+}
+
+func checkGHToken(ctx *config.Context) Result {
+	if _, err := auth.Token(ctx.Hostname, ctx.User); err != nil {
+		return fail(CheckGHToken, ctx.Name, SeverityError,
+			fmt.Sprintf("gh auth login --hostname %s --username %s --scopes repo,read:org", ctx.Hostname, ctx.User),
+			"No gh token stored for %s@%s", ctx.User, ctx.Hostname)
+	}
+	return pass(CheckGHToken, ctx.Name, SeverityError, "gh token stored for %s@%s", ctx.User, ctx.Hostname)
+}
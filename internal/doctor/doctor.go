@@ -0,0 +1,104 @@
+// ABOUTME: Diagnostic checks for gh-context setups
+// ABOUTME: Runs stable, machine-readable checks over contexts, SSH and gh auth state
+
+package doctor
+
+// Severity describes how serious a failed check is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // The context will not work
+	SeverityWarning Severity = "warning" // The context works but something is off
+	SeverityInfo    Severity = "info"    // Worth knowing, nothing to fix
+)
+
+// Status is the outcome of a single check.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip" // Not applicable or could not be determined
+)
+
+// Check IDs are stable so scripts can match on them.
+const (
+	CheckActivePointer  = "active-pointer"
+	CheckRepoBinding    = "repo-binding"
+	CheckRules          = "rules"
+	CheckContextFile    = "context-file"
+	CheckSSHKeyExists   = "ssh-key-exists"
+	CheckSSHKeyPerms    = "ssh-key-permissions"
+	CheckSSHPublicKey   = "ssh-public-key"
+	CheckSSHAgent       = "ssh-agent-loaded"
+	CheckSSHConfigMatch = "ssh-config-match"
+	CheckGHToken        = "gh-token"
+	CheckSSHIdentity    = "ssh-identity"
+)
+
+// IsGlobal reports whether a check ID is about overall state rather than one context.
+func IsGlobal(id string) bool {
+	return id == CheckActivePointer || id == CheckRepoBinding || id == CheckRules
+}
+
+// Result is the outcome of one check, for one context where applicable.
+type Result struct {
+	ID       string   `json:"id"`
+	Context  string   `json:"context,omitempty"`
+	Severity Severity `json:"severity"`
+	Status   Status   `json:"status"`
+	Message  string   `json:"message"`
+	Hint     string   `json:"hint,omitempty"`
+}
+
+// Failed reports whether the check failed.
+func (r Result) Failed() bool {
+	return r.Status == StatusFail
+}
+
+// Options controls which checks run.
+type Options struct {
+	Contexts  []string // Limit per-context checks to these names (default: all)
+	VerifySSH bool     // Connect to git@<host> to confirm the key's account
+}
+
+// Run executes all checks and returns their results in a stable order:
+// global checks first, then per-context checks in context order.
+func Run(opts Options) ([]Result, error) {
+	var results []Result
+
+	results = append(results, checkActivePointer())
+	results = append(results, checkRepoBinding())
+	results = append(results, checkRules())
+
+	names, err := selectContexts(opts.Contexts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		ctx, result := checkContextFile(name)
+		results = append(results, result)
+		if ctx != nil {
+			results = append(results, contextChecks(ctx, opts)...)
+		}
+	}
+
+	return results, nil
+}
+
+// Summary counts failures by severity.
+func Summary(results []Result) (errors, warnings int) {
+	for _, r := range results {
+		if !r.Failed() {
+			continue
+		}
+		switch r.Severity {
+		case SeverityError:
+			errors++
+		case SeverityWarning:
+			warnings++
+		}
+	}
+	return errors, warnings
+}
@@ -0,0 +1,78 @@
+// ABOUTME: Atomic file writes for gh-context
+// ABOUTME: Writes to a temp file in the target directory and renames it into place
+
+package fileutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes data to path so that readers see either the old or the
+// new content, never a partial file. The data is written to a temp file in the
+// same directory, synced, and renamed over path. If path is a symlink, the file
+// it points to is replaced and the link is kept.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	path, err = resolveSymlinks(path)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	// Clean up the temp file on any failure
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", path, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+	if err = os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %w", path, err)
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// resolveSymlinks follows path through any symlinks to the file they point
+// to, so that renaming over it keeps links such as a dotfile manager's
+// ~/.ssh/config. The target need not exist yet.
+func resolveSymlinks(path string) (string, error) {
+	for i := 0; i < 40; i++ {
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) || (err == nil && info.Mode()&os.ModeSymlink == 0) {
+			return path, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	return "", fmt.Errorf("failed to resolve %s: too many levels of symbolic links", path)
+}
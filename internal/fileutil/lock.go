@@ -0,0 +1,45 @@
+// ABOUTME: Cross-process file locks for gh-context
+// ABOUTME: Serializes read-modify-write cycles on shared config files
+
+package fileutil
+
+import (
+	"fmt"
+	"os"
+)
+
+// Lock is an exclusive advisory lock held on a lock file.
+// Locks are per open file, so two Lock calls on the same path block each other
+// even within one process; never nest locks on the same path.
+type Lock struct {
+	file *os.File
+}
+
+// LockFile blocks until it holds an exclusive lock on path, creating it if needed.
+// The lock file is left in place after Unlock; deleting it would race with waiters.
+func LockFile(path string) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	return &Lock{file: f}, nil
+}
+
+// Unlock releases the lock.
+func (l *Lock) Unlock() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	err := unlockFile(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
+}
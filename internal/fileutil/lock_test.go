@@ -0,0 +1,148 @@
+package fileutil
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// incrementCounter does one locked read-modify-write of the counter at path.
+func incrementCounter(path string) error {
+	lock, err := LockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	n := 0
+	if len(data) > 0 {
+		if n, err = strconv.Atoi(strings.TrimSpace(string(data))); err != nil {
+			return fmt.Errorf("counter file is corrupt: %q", data)
+		}
+	}
+	return WriteFileAtomic(path, []byte(strconv.Itoa(n+1)+"\n"), 0600)
+}
+
+// readCounter returns the counter at path, failing on partial content.
+func readCounter(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatalf("counter file is corrupt: %q", data)
+	}
+	return n
+}
+
+func TestLockFileSerializesGoroutines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter")
+	if err := os.WriteFile(path, []byte("0\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, rounds = 8, 25
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		// Readers never take the lock; atomic writes must still never show a
+		// truncated file
+		defer readers.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Errorf("read during writes: %v", err)
+				return
+			}
+			if _, err := strconv.Atoi(strings.TrimSpace(string(data))); err != nil {
+				t.Errorf("reader saw partial content: %q", data)
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				if err := incrementCounter(path); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	if got := readCounter(t, path); got != workers*rounds {
+		t.Errorf("counter = %d, want %d (updates were lost)", got, workers*rounds)
+	}
+}
+
+// TestLockFileHelperProcess is run as a subprocess by
+// TestLockFileSerializesProcesses; it is not a real test.
+func TestLockFileHelperProcess(t *testing.T) {
+	path := os.Getenv("GH_CONTEXT_LOCK_TEST_COUNTER")
+	if path == "" {
+		t.Skip("helper process")
+	}
+	rounds, _ := strconv.Atoi(os.Getenv("GH_CONTEXT_LOCK_TEST_ROUNDS"))
+	for r := 0; r < rounds; r++ {
+		if err := incrementCounter(path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	os.Exit(0)
+}
+
+func TestLockFileSerializesProcesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns processes")
+	}
+	path := filepath.Join(t.TempDir(), "counter")
+
+	const procs, rounds = 4, 25
+	cmds := make([]*exec.Cmd, procs)
+	for i := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestLockFileHelperProcess$")
+		cmd.Env = append(os.Environ(),
+			"GH_CONTEXT_LOCK_TEST_COUNTER="+path,
+			"GH_CONTEXT_LOCK_TEST_ROUNDS="+strconv.Itoa(rounds))
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmds[i] = cmd
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("helper process failed: %v", err)
+		}
+	}
+
+	if got := readCounter(t, path); got != procs*rounds {
+		t.Errorf("counter = %d, want %d (updates were lost)", got, procs*rounds)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+// ABOUTME: flock-based file locking for Unix platforms
+// ABOUTME: Implements lockFile/unlockFile used by LockFile
+
+package fileutil
+
+import (
+	"os"
+	"syscall"
+)
+
+func lockFile(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,272 @@
+// ABOUTME: Git config operations for gh-context
+// ABOUTME: Reads and writes user identity settings in repo-local or global git config
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Scope selects which git config file is read or written.
+type Scope string
+
+const (
+	ScopeGlobal Scope = "global" // ~/.gitconfig
+	ScopeLocal  Scope = "local"  // .git/config of the current repository
+)
+
+// ParseScope converts a string flag value into a Scope.
+func ParseScope(s string) (Scope, error) {
+	switch Scope(s) {
+	case ScopeGlobal, ScopeLocal:
+		return Scope(s), nil
+	default:
+		return "", fmt.Errorf("git scope must be 'global' or 'local', got: %s", s)
+	}
+}
+
+// GetConfig reads a single git config value.
+// Returns empty string if the key is not set.
+func GetConfig(scope Scope, key string) (string, error) {
+	cmd := exec.Command("git", "config", "--"+string(scope), "--get", key)
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil // Key not set
+		}
+		return "", fmt.Errorf("git config --get %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// SetConfig writes a single git config value.
+func SetConfig(scope Scope, key, value string) error {
+	cmd := exec.Command("git", "config", "--"+string(scope), key, value)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git config %s: %s", key, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// UnsetConfig removes a git config value. Missing keys are not an error.
+func UnsetConfig(scope Scope, key string) error {
+	cmd := exec.Command("git", "config", "--"+string(scope), "--unset", key)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 5 {
+			return nil // Key was not set
+		}
+		return fmt.Errorf("git config --unset %s: %s", key, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Identity is the git author identity carried by a context.
+type Identity struct {
+	Name          string
+	Email         string
+	SigningKey    string
+	SigningFormat string // gpg or ssh
+}
+
+// String formats the identity the way git shows authors ("Name <email>").
+func (id Identity) String() string {
+	switch {
+	case id.Email == "":
+		return id.Name
+	case id.Name == "":
+		return "<" + id.Email + ">"
+	default:
+		return id.Name + " <" + id.Email + ">"
+	}
+}
+
+// entries returns the git config keys and values for this identity.
+func (id Identity) entries() [][2]string {
+	var entries [][2]string
+	if id.Name != "" {
+		entries = append(entries, [2]string{"user.name", id.Name})
+	}
+	if id.Email != "" {
+		entries = append(entries, [2]string{"user.email", id.Email})
+	}
+	if id.SigningKey != "" {
+		entries = append(entries, [2]string{"user.signingkey", id.SigningKey})
+		entries = append(entries, [2]string{"commit.gpgsign", "true"})
+		if id.SigningFormat != "" {
+			entries = append(entries, [2]string{"gpg.format", id.SigningFormat})
+		}
+	}
+	return entries
+}
+
+// ApplyIdentity writes the identity into git config at the given scope.
+func ApplyIdentity(scope Scope, id Identity) error {
+	for _, e := range id.entries() {
+		if err := SetConfig(scope, e[0], e[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// identityKeys are the git config keys an Identity can write.
+var identityKeys = []string{"user.name", "user.email", "user.signingkey", "commit.gpgsign", "gpg.format"}
+
+// ReadIdentityConfig returns the current values of the keys an identity can
+// write, so they can be restored later. Keys that are not set are left out.
+func ReadIdentityConfig(scope Scope) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, key := range identityKeys {
+		value, err := GetConfig(scope, key)
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// RevertIdentity undoes the identity in git config at the given scope.
+// Keys are only touched while they still hold the value the identity set,
+// so anything the user changed by hand is left alone. Each key gets back its
+// value from prior (see ReadIdentityConfig), or is removed if prior has none.
+func RevertIdentity(scope Scope, id Identity, prior map[string]string) error {
+	for _, e := range id.entries() {
+		current, err := GetConfig(scope, e[0])
+		if err != nil {
+			return err
+		}
+		if current != e[1] {
+			continue
+		}
+		if value, ok := prior[e[0]]; ok {
+			err = SetConfig(scope, e[0], value)
+		} else {
+			err = UnsetConfig(scope, e[0])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetSSHAliasRewrite makes git send SSH URLs for hostname through an SSH Host alias,
+// via url.<alias>.insteadOf for both the scp-like and ssh:// URL forms.
+func SetSSHAliasRewrite(scope Scope, hostname, alias string) error {
+	rewrites := [][2]string{
+		{"git@" + alias + ":", "git@" + hostname + ":"},
+		{"ssh://git@" + alias + "/", "ssh://git@" + hostname + "/"},
+	}
+	for _, r := range rewrites {
+		if err := SetConfig(scope, "url."+r[0]+".insteadOf", r[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveSSHAliasRewrite removes the URL rewrites created by SetSSHAliasRewrite.
+func RemoveSSHAliasRewrite(scope Scope, alias string) error {
+	for _, base := range []string{"git@" + alias + ":", "ssh://git@" + alias + "/"} {
+		if err := UnsetConfig(scope, "url."+base+".insteadOf"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAllConfig reads every value of a multi-valued git config key, in order.
+// Returns nil if the key is not set.
+func GetAllConfig(scope Scope, key string) ([]string, error) {
+	cmd := exec.Command("git", "config", "--"+string(scope), "--get-all", key)
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return nil, nil // Key not set
+		}
+		return nil, fmt.Errorf("git config --get-all %s: %w", key, err)
+	}
+	return strings.Split(strings.TrimSuffix(string(output), "\n"), "\n"), nil
+}
+
+// AddConfig appends a value to a multi-valued git config key.
+func AddConfig(scope Scope, key, value string) error {
+	cmd := exec.Command("git", "config", "--"+string(scope), "--add", key, value)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git config --add %s: %s", key, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// CredentialKey returns the git config key of a credential setting scoped to
+// HTTPS URLs on hostname, e.g. credential.https://github.com.username.
+func CredentialKey(hostname, setting string) string {
+	return "credential.https://" + hostname + "." + setting
+}
+
+// EnsureCredentialHelper makes helper the only credential helper git uses for
+// HTTPS URLs on hostname, the way 'gh auth setup-git' does: an empty entry
+// clears helpers configured before it (a keychain or store helper), then
+// helper is added. Nothing is written if helper is already in effect.
+// Returns true if git config was changed.
+func EnsureCredentialHelper(scope Scope, hostname, helper string) (bool, error) {
+	key := CredentialKey(hostname, "helper")
+	values, err := GetAllConfig(scope, key)
+	if err != nil {
+		return false, err
+	}
+
+	// Already cleared and set, by us or by gh auth setup-git
+	for i := len(values) - 1; i >= 0 && values[i] != ""; i-- {
+		if isGhCredentialHelper(values[i]) && contains(values[:i], "") {
+			return false, nil
+		}
+	}
+
+	if err := AddConfig(scope, key, ""); err != nil {
+		return false, err
+	}
+	return true, AddConfig(scope, key, helper)
+}
+
+// isGhCredentialHelper reports whether a credential helper value runs gh.
+func isGhCredentialHelper(helper string) bool {
+	return strings.HasSuffix(strings.TrimSpace(helper), "auth git-credential")
+}
+
+// contains reports whether values holds s.
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCredentialUser tells git which account to request from credential
+// helpers for HTTPS URLs on hostname.
+func SetCredentialUser(scope Scope, hostname, user string) error {
+	return SetConfig(scope, CredentialKey(hostname, "username"), user)
+}
+
+// RevertCredentialUser removes the username set by SetCredentialUser, unless
+// it has since been changed to another account.
+func RevertCredentialUser(scope Scope, hostname, user string) error {
+	key := CredentialKey(hostname, "username")
+	current, err := GetConfig(scope, key)
+	if err != nil || current != user {
+		return err
+	}
+	return UnsetConfig(scope, key)
+}
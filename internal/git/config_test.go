@@ -0,0 +1,75 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useTempGlobalConfig points git's global config at an empty temp file.
+func useTempGlobalConfig(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "gitconfig")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", path)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+}
+
+func TestRevertIdentityRestoresPriorValues(t *testing.T) {
+	useTempGlobalConfig(t)
+	for key, value := range map[string]string{"user.name": "Own Name", "commit.gpgsign": "true"} {
+		if err := SetConfig(ScopeGlobal, key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prior, err := ReadIdentityConfig(ScopeGlobal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := Identity{Name: "Work Name", Email: "work@example.com", SigningKey: "ABC123", SigningFormat: "gpg"}
+	if err := ApplyIdentity(ScopeGlobal, id); err != nil {
+		t.Fatal(err)
+	}
+	// Changed by hand while the context was active: must survive the revert
+	if err := SetConfig(ScopeGlobal, "user.email", "mine@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RevertIdentity(ScopeGlobal, id, prior); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"user.name":       "Own Name",
+		"user.email":      "mine@example.com",
+		"user.signingkey": "",
+		"commit.gpgsign":  "true",
+		"gpg.format":      "",
+	}
+	for key, value := range want {
+		got, err := GetConfig(ScopeGlobal, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestRevertIdentityWithoutPriorUnsets(t *testing.T) {
+	useTempGlobalConfig(t)
+	id := Identity{Name: "Work Name"}
+	if err := ApplyIdentity(ScopeGlobal, id); err != nil {
+		t.Fatal(err)
+	}
+	if err := RevertIdentity(ScopeGlobal, id, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetConfig(ScopeGlobal, "user.name"); got != "" {
+		t.Errorf("user.name = %q, want it unset", got)
+	}
+}
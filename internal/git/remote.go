@@ -0,0 +1,235 @@
+// ABOUTME: Git remote URL parsing for gh-context
+// ABOUTME: Extracts host, owner and repo from SSH, scp-like and HTTPS remote URLs
+
+package git
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Remote identifies a repository on a GitHub host.
+type Remote struct {
+	Host  string // Hostname, or SSH alias as written in the URL
+	Owner string
+	Repo  string // Without a .git suffix
+}
+
+// String returns the remote as host/owner/repo.
+func (r *Remote) String() string {
+	return r.Host + "/" + r.Owner + "/" + r.Repo
+}
+
+// RemoteURLAt returns the configured URL of a remote in the repo at root, as
+// written in .git/config (before insteadOf rewrites). Returns empty string if
+// the remote does not exist.
+func RemoteURLAt(root, name string) (string, error) {
+	cmd := exec.Command("git", "-C", root, "config", "--get", "remote."+name+".url")
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return "", nil // Not set
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// OriginAt returns the parsed origin remote of the repo at root.
+// Returns nil if the repo has no origin.
+func OriginAt(root string) (*Remote, error) {
+	rawURL, err := RemoteURLAt(root, "origin")
+	if err != nil || rawURL == "" {
+		return nil, err
+	}
+	return ParseRemoteURL(rawURL)
+}
+
+// ReadOriginAt is OriginAt without running git: it reads remote.origin.url
+// straight from the repo's config file (see ConfigFileAt). Included config
+// files are not followed.
+func ReadOriginAt(root string) (*Remote, error) {
+	data, err := os.ReadFile(ConfigFileAt(root))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	rawURL := readConfigValue(string(data), "remote", "origin", "url")
+	if rawURL == "" {
+		return nil, nil
+	}
+	return ParseRemoteURL(rawURL)
+}
+
+// readConfigValue returns the last value of section.subsection.key in git
+// config text, or "" if it is not set. Section and key names are matched
+// case-insensitively, the subsection exactly, as git does.
+func readConfigValue(text, section, subsection, key string) string {
+	value := ""
+	inSection := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.LastIndex(line, "]")
+			if end < 0 {
+				inSection = false
+				continue
+			}
+			name, sub, _ := strings.Cut(line[1:end], " ")
+			sub = strings.TrimSpace(sub)
+			if unquoted, err := strconv.Unquote(sub); err == nil {
+				sub = unquoted
+			}
+			inSection = strings.EqualFold(name, section) && sub == subsection
+			line = strings.TrimSpace(line[end+1:]) // "[section] key = value" is valid
+			if line == "" {
+				continue
+			}
+		}
+		if !inSection {
+			continue
+		}
+
+		k, v, _ := strings.Cut(line, "=")
+		if strings.EqualFold(strings.TrimSpace(k), key) {
+			value = unquoteConfigValue(strings.TrimSpace(v))
+		}
+	}
+	return value
+}
+
+// unquoteConfigValue strips quotes and trailing comments from a git config value.
+func unquoteConfigValue(v string) string {
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && i+1 < len(v):
+			i++
+			b.WriteByte(v[i])
+		case (c == '#' || c == ';') && !quoted:
+			return strings.TrimSpace(b.String())
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// ParseRemoteURL parses the remote URL forms git accepts for GitHub repos:
+//
+//	git@github.com:owner/repo.git
+//	ssh://git@github.com:22/owner/repo.git
+//	https://github.com/owner/repo.git
+//	git://github.com/owner/repo
+func ParseRemoteURL(rawURL string) (*Remote, error) {
+	host, path, err := splitRemoteURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	path = strings.Trim(path, "/")
+	path = strings.TrimSuffix(path, ".git")
+	parts := strings.Split(path, "/")
+	if host == "" || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("not a host/owner/repo remote URL: %s", rawURL)
+	}
+
+	return &Remote{Host: strings.ToLower(host), Owner: parts[0], Repo: parts[1]}, nil
+}
+
+// splitRemoteURL returns the host and path of a URL or scp-like address.
+func splitRemoteURL(rawURL string) (string, string, error) {
+	if strings.Contains(rawURL, "://") {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid remote URL %s: %w", rawURL, err)
+		}
+		return u.Hostname(), u.Path, nil
+	}
+
+	// scp-like: [user@]host:path. A slash before the colon makes it a local path
+	colon := strings.Index(rawURL, ":")
+	if colon < 0 || strings.Contains(rawURL[:colon], "/") {
+		return "", "", fmt.Errorf("not a remote URL: %s", rawURL)
+	}
+	host := rawURL[:colon]
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	return host, rawURL[colon+1:], nil
+}
+
+// SSHURL returns the scp-like SSH URL of the repo on host (a hostname or SSH alias).
+func (r *Remote) SSHURL(host string) string {
+	return "git@" + host + ":" + r.Owner + "/" + r.Repo + ".git"
+}
+
+// HTTPSURL returns the HTTPS URL of the repo on host.
+func (r *Remote) HTTPSURL(host string) string {
+	return "https://" + host + "/" + r.Owner + "/" + r.Repo + ".git"
+}
+
+// RemoteURLEntry is one url or pushurl setting of a remote.
+type RemoteURLEntry struct {
+	Remote string // Remote name, e.g. origin
+	Key    string // url or pushurl
+	URL    string
+}
+
+// ConfigKey returns the git config key of the entry, e.g. remote.origin.url.
+func (e RemoteURLEntry) ConfigKey() string {
+	return "remote." + e.Remote + "." + e.Key
+}
+
+// RemoteURLsAt lists the url and pushurl settings of every remote of the repo
+// at root, in config order.
+func RemoteURLsAt(root string) ([]RemoteURLEntry, error) {
+	cmd := exec.Command("git", "-C", root, "config", "--get-regexp", `^remote\..*\.(url|pushurl)$`)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return nil, nil // No remotes
+		}
+		return nil, err
+	}
+
+	var entries []RemoteURLEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		dot := strings.LastIndex(key, ".")
+		if !strings.HasPrefix(key, "remote.") || dot <= len("remote.") {
+			continue
+		}
+		entries = append(entries, RemoteURLEntry{
+			Remote: key[len("remote."):dot],
+			Key:    key[dot+1:],
+			URL:    value,
+		})
+	}
+	return entries, nil
+}
+
+// SetRemoteURLAt replaces the URL of one remote setting in the repo at root,
+// leaving any other values of the same key alone.
+func SetRemoteURLAt(root string, e RemoteURLEntry, newURL string) error {
+	cmd := exec.Command("git", "-C", root, "config", "--replace-all",
+		e.ConfigKey(), newURL, "^"+regexp.QuoteMeta(e.URL)+"$")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git config %s: %s", e.ConfigKey(), strings.TrimSpace(string(output)))
+	}
+	return nil
+}
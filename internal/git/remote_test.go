@@ -0,0 +1,94 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadConfigValue(t *testing.T) {
+	text := `[core]
+	repositoryformatversion = 0
+[remote "upstream"]
+	url = git@github.com:other/repo.git
+[Remote "origin"]
+	fetch = +refs/heads/*:refs/remotes/origin/*
+	URL = "git@github.com-work:acme/app.git" ; set by gh-context
+[remote "Origin"]
+	url = https://example.com/wrong-case.git
+`
+	if got := readConfigValue(text, "remote", "origin", "url"); got != "git@github.com-work:acme/app.git" {
+		t.Errorf("remote.origin.url = %q", got)
+	}
+	if got := readConfigValue(text, "remote", "missing", "url"); got != "" {
+		t.Errorf("remote.missing.url = %q, want empty", got)
+	}
+}
+
+func TestReadOriginAt(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if origin, err := ReadOriginAt(root); err != nil || origin != nil {
+		t.Fatalf("repo without config: origin = %v, %v; want nil", origin, err)
+	}
+
+	config := "[remote \"origin\"]\n\turl = https://github.com/acme/app.git\n"
+	if err := os.WriteFile(filepath.Join(root, ".git", "config"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	origin, err := ReadOriginAt(root)
+	if err != nil || origin == nil || origin.String() != "github.com/acme/app" {
+		t.Errorf("origin = %v, %v; want github.com/acme/app", origin, err)
+	}
+}
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    Remote
+		wantErr bool
+	}{
+		{url: "git@github.com:owner/repo.git", want: Remote{"github.com", "owner", "repo"}},
+		{url: "git@github.com:owner/repo", want: Remote{"github.com", "owner", "repo"}},
+		{url: "github.com:owner/repo.git", want: Remote{"github.com", "owner", "repo"}},
+		{url: "git@github.com-work:acme/app.git", want: Remote{"github.com-work", "acme", "app"}},
+		{url: "git@GitHub.com:Owner/Repo.git", want: Remote{"github.com", "Owner", "Repo"}},
+		{url: "ssh://git@github.com/owner/repo.git", want: Remote{"github.com", "owner", "repo"}},
+		{url: "ssh://git@github.com:22/owner/repo.git", want: Remote{"github.com", "owner", "repo"}},
+		{url: "ssh://git@ghe.corp:2222/owner/repo", want: Remote{"ghe.corp", "owner", "repo"}},
+		{url: "https://github.com/owner/repo.git", want: Remote{"github.com", "owner", "repo"}},
+		{url: "https://github.com/owner/repo", want: Remote{"github.com", "owner", "repo"}},
+		{url: "https://github.com/owner/repo/", want: Remote{"github.com", "owner", "repo"}},
+		{url: "https://user@github.com:443/owner/repo.git", want: Remote{"github.com", "owner", "repo"}},
+		{url: "git://github.com/owner/repo", want: Remote{"github.com", "owner", "repo"}},
+		{url: "https://github.com/owner/my.repo.git", want: Remote{"github.com", "owner", "my.repo"}},
+		{url: "https://github.com/owner", wantErr: true},
+		{url: "https://github.com/group/sub/repo.git", wantErr: true},
+		{url: "git@github.com:repo.git", wantErr: true},
+		{url: "/srv/git/owner/repo.git", wantErr: true},
+		{url: "./owner:repo", wantErr: true},
+		{url: "file:///srv/git/owner/repo.git", wantErr: true},
+		{url: "https://%zz/owner/repo", wantErr: true},
+		{url: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := ParseRemoteURL(tt.url)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseRemoteURL(%q) = %+v, want error", tt.url, *got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRemoteURL(%q): %v", tt.url, err)
+			}
+			if *got != tt.want {
+				t.Errorf("ParseRemoteURL(%q) = %+v, want %+v", tt.url, *got, tt.want)
+			}
+		})
+	}
+}
@@ -25,6 +25,67 @@ func RepoRoot() (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// RepoRootAt returns the root of the git repository containing dir.
+// Returns empty string if dir is not in a git repository.
+func RepoRootAt(dir string) (string, error) {
+	cmd := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", nil
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// FindRepoRoot walks up from dir to the nearest directory containing .git
+// (a directory, or a file for worktrees and submodules) without running git.
+// Returns empty string if dir is not in a git repository.
+func FindRepoRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ConfigFileAt returns the path of the git config file of the repo at root,
+// following the gitdir and commondir links of worktrees.
+func ConfigFileAt(root string) string {
+	gitDir := filepath.Join(root, ".git")
+	info, err := os.Stat(gitDir)
+	if err != nil || info.IsDir() {
+		return filepath.Join(gitDir, "config")
+	}
+
+	// .git is a file: "gitdir: <path>"
+	data, err := os.ReadFile(gitDir)
+	if err != nil {
+		return filepath.Join(gitDir, "config")
+	}
+	target := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(data)), "gitdir:"))
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(root, target)
+	}
+
+	// Linked worktrees share the config of the main repository
+	if common, err := os.ReadFile(filepath.Join(target, "commondir")); err == nil {
+		dir := strings.TrimSpace(string(common))
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(target, dir)
+		}
+		target = dir
+	}
+	return filepath.Join(target, "config")
+}
+
 // GetBinding reads the context name from .ghcontext in the repo root.
 // Returns empty string if no binding exists.
 func GetBinding() (string, error) {
@@ -35,7 +96,12 @@ func GetBinding() (string, error) {
 	if root == "" {
 		return "", nil
 	}
+	return ReadBindingAt(root)
+}
 
+// ReadBindingAt reads the context name from .ghcontext in the given repo root.
+// Returns empty string if no binding exists.
+func ReadBindingAt(root string) (string, error) {
 	bindingPath := filepath.Join(root, ghContextFile)
 	data, err := os.ReadFile(bindingPath)
 	if err != nil {
@@ -48,6 +114,11 @@ func GetBinding() (string, error) {
 	return strings.TrimSpace(string(data)), nil
 }
 
+// BindingPathAt returns the path to .ghcontext in the given repo root.
+func BindingPathAt(root string) string {
+	return filepath.Join(root, ghContextFile)
+}
+
 // SetBinding writes a context name to .ghcontext in the repo root.
 func SetBinding(contextName string) error {
 	root, err := RepoRoot()
@@ -57,7 +128,11 @@ func SetBinding(contextName string) error {
 	if root == "" {
 		return fmt.Errorf("not inside a Git repository")
 	}
+	return WriteBindingAt(root, contextName)
+}
 
+// WriteBindingAt writes a context name to .ghcontext in the given repo root.
+func WriteBindingAt(root, contextName string) error {
 	bindingPath := filepath.Join(root, ghContextFile)
 	return os.WriteFile(bindingPath, []byte(contextName+"\n"), 0644)
 }
@@ -0,0 +1,189 @@
+// ABOUTME: ssh-agent client for gh-context
+// ABOUTME: Lists, loads and unloads context keys over the agent protocol at $SSH_AUTH_SOCK
+
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// ErrNoAgent is returned when no ssh-agent is reachable.
+var ErrNoAgent = errors.New("no ssh-agent available (SSH_AUTH_SOCK not set or agent not running)")
+
+// Agent is a connection to an ssh-agent.
+type Agent struct {
+	client agent.Agent
+	conn   net.Conn // Nil for agents not reached over a socket
+}
+
+// ConnectAgent connects to the ssh-agent at $SSH_AUTH_SOCK.
+// Returns ErrNoAgent if there is none.
+func ConnectAgent() (*Agent, error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, ErrNoAgent
+	}
+	conn, err := net.DialTimeout("unix", sock, 2*time.Second)
+	if err != nil {
+		return nil, ErrNoAgent
+	}
+	return &Agent{client: agent.NewClient(conn), conn: conn}, nil
+}
+
+// NewAgent wraps an agent implementation, such as agent.NewKeyring().
+func NewAgent(client agent.Agent) *Agent {
+	return &Agent{client: client}
+}
+
+// Close closes the connection to the agent.
+func (a *Agent) Close() error {
+	if a.conn == nil {
+		return nil
+	}
+	return a.conn.Close()
+}
+
+// HasKey reports whether the agent holds the key at keyPath.
+func (a *Agent) HasKey(keyPath string) (bool, error) {
+	pub, err := loadPublicKey(keyPath)
+	if err != nil {
+		return false, err
+	}
+
+	keys, err := a.client.List()
+	if err != nil {
+		return false, fmt.Errorf("cannot list ssh-agent keys: %w", err)
+	}
+	want := pub.Marshal()
+	for _, k := range keys {
+		if bytes.Equal(k.Blob, want) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Signer returns a signer that signs with the agent's copy of pub.
+func (a *Agent) Signer(pub gossh.PublicKey) (gossh.Signer, error) {
+	signers, err := a.client.Signers()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list ssh-agent keys: %w", err)
+	}
+	want := pub.Marshal()
+	for _, s := range signers {
+		if bytes.Equal(s.PublicKey().Marshal(), want) {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("ssh-agent does not hold the key")
+}
+
+// AddKey loads the key at keyPath into the agent, replacing any lifetime it
+// was loaded with before. A lifetime of 0 keeps it until it is removed.
+// Passphrase-protected keys are handed to ssh-add, which asks on the terminal.
+func (a *Agent) AddKey(keyPath string, lifetime time.Duration) error {
+	path := ExpandPath(keyPath)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read SSH key: %w", err)
+	}
+
+	key, err := gossh.ParseRawPrivateKey(data)
+	var missing *gossh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		if a.conn == nil {
+			return fmt.Errorf("SSH key %s is passphrase-protected", keyPath)
+		}
+		return sshAdd(path, lifetime)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot parse SSH key %s: %w", keyPath, err)
+	}
+
+	// Drop the old copy so its lifetime doesn't outlive the new one
+	if pub, err := loadPublicKey(keyPath); err == nil {
+		_ = a.client.Remove(pub)
+	}
+
+	return a.client.Add(agent.AddedKey{
+		PrivateKey:   key,
+		Comment:      keyPath,
+		LifetimeSecs: uint32(lifetime / time.Second),
+	})
+}
+
+// RemoveKey unloads the key at keyPath from the agent.
+// Returns false if the agent did not hold it.
+func (a *Agent) RemoveKey(keyPath string) (bool, error) {
+	loaded, err := a.HasKey(keyPath)
+	if err != nil || !loaded {
+		return false, err
+	}
+	pub, err := loadPublicKey(keyPath)
+	if err != nil {
+		return false, err
+	}
+	if err := a.client.Remove(pub); err != nil {
+		return false, fmt.Errorf("cannot remove %s from ssh-agent: %w", keyPath, err)
+	}
+	return true, nil
+}
+
+// loadPublicKey reads the public half of the key at keyPath: from its .pub
+// file, or else from the private key when it isn't passphrase-protected.
+func loadPublicKey(keyPath string) (gossh.PublicKey, error) {
+	if data, err := os.ReadFile(PublicKeyPath(keyPath)); err == nil {
+		pub, _, _, _, err := gossh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("public key %s is malformed: %w", PublicKeyPath(keyPath), err)
+		}
+		return pub, nil
+	}
+
+	data, err := os.ReadFile(ExpandPath(keyPath))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read public key: %w", err)
+	}
+	signer, err := gossh.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read public key: %s not found and %w", PublicKeyPath(keyPath), err)
+	}
+	return signer.PublicKey(), nil
+}
+
+// sshAdd loads a key with ssh-add, which can prompt for its passphrase.
+func sshAdd(path string, lifetime time.Duration) error {
+	args := []string{path}
+	if lifetime > 0 {
+		args = []string{"-t", strconv.Itoa(int(lifetime / time.Second)), path}
+	}
+	cmd := exec.Command("ssh-add", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ssh-add %s failed: %w", path, err)
+	}
+	return nil
+}
+
+// AgentHasKey reports whether the ssh-agent at $SSH_AUTH_SOCK holds the key
+// at keyPath.
+func AgentHasKey(keyPath string) (bool, error) {
+	a, err := ConnectAgent()
+	if err != nil {
+		return false, err
+	}
+	defer a.Close()
+	return a.HasKey(keyPath)
+}
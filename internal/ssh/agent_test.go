@@ -0,0 +1,130 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// writeTestKey creates an ed25519 keypair at dir/name and dir/name.pub.
+// With a passphrase, the private key is encrypted.
+func writeTestKey(t *testing.T, dir, name, passphrase string) (string, gossh.PublicKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = gossh.MarshalPrivateKey(priv, name)
+	} else {
+		block, err = gossh.MarshalPrivateKeyWithPassphrase(priv, name, []byte(passphrase))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshPub, err := gossh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".pub", gossh.MarshalAuthorizedKey(sshPub), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, sshPub
+}
+
+func TestAgentAddHasRemove(t *testing.T) {
+	dir := t.TempDir()
+	work, _ := writeTestKey(t, dir, "id_work", "")
+	personal, _ := writeTestKey(t, dir, "id_personal", "")
+	a := NewAgent(agent.NewKeyring())
+
+	if loaded, err := a.HasKey(work); err != nil || loaded {
+		t.Fatalf("HasKey before AddKey = %v, %v; want false", loaded, err)
+	}
+	if err := a.AddKey(work, 0); err != nil {
+		t.Fatal(err)
+	}
+	// Adding again replaces the key rather than holding two copies
+	if err := a.AddKey(work, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddKey(personal, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := a.client.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("agent holds %d keys, want 2", len(keys))
+	}
+
+	removed, err := a.RemoveKey(work)
+	if err != nil || !removed {
+		t.Fatalf("RemoveKey = %v, %v; want true", removed, err)
+	}
+	if removed, err := a.RemoveKey(work); err != nil || removed {
+		t.Errorf("second RemoveKey = %v, %v; want false", removed, err)
+	}
+	if loaded, _ := a.HasKey(work); loaded {
+		t.Error("work key still loaded after RemoveKey")
+	}
+	if loaded, _ := a.HasKey(personal); !loaded {
+		t.Error("personal key was unloaded with the work key")
+	}
+}
+
+func TestAgentPublicKeyFromPrivateKey(t *testing.T) {
+	path, pub := writeTestKey(t, t.TempDir(), "id_work", "")
+	if err := os.Remove(path + ".pub"); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAgent(agent.NewKeyring())
+	if err := a.AddKey(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := a.HasKey(path); err != nil || !loaded {
+		t.Errorf("HasKey without .pub = %v, %v; want true", loaded, err)
+	}
+	if _, err := a.Signer(pub); err != nil {
+		t.Errorf("Signer: %v", err)
+	}
+}
+
+func TestAgentPassphraseKeyNeedsSocket(t *testing.T) {
+	path, _ := writeTestKey(t, t.TempDir(), "id_work", "secret")
+
+	// ssh-add can only load into a real agent; an in-process one must say so
+	// instead of running it
+	a := NewAgent(agent.NewKeyring())
+	if err := a.AddKey(path, 0); err == nil {
+		t.Fatal("AddKey of a passphrase-protected key succeeded without ssh-add")
+	}
+	if loaded, err := a.HasKey(path); err != nil || loaded {
+		t.Errorf("HasKey = %v, %v; want false", loaded, err)
+	}
+}
+
+func TestConnectAgentWithoutSocket(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	if _, err := ConnectAgent(); !errors.Is(err, ErrNoAgent) {
+		t.Errorf("ConnectAgent error = %v, want ErrNoAgent", err)
+	}
+}
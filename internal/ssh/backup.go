@@ -0,0 +1,240 @@
+// ABOUTME: Timestamped, rotated backups of ~/.ssh/config for gh-context
+// ABOUTME: Lists, creates, prunes and restores backups taken before each change
+
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peterjmorgan/gh-context/internal/fileutil"
+)
+
+// DefaultBackupRetention is the number of backups kept when
+// GH_CONTEXT_SSH_BACKUPS is not set.
+const DefaultBackupRetention = 10
+
+// backupTimeFormat is used for backup IDs; it sorts chronologically.
+const backupTimeFormat = "20060102-150405.000"
+
+// Backup is a saved copy of an SSH config file.
+type Backup struct {
+	ID   string    // Timestamp-based identifier (e.g., 20261017-153012.123)
+	Path string    // Full path to the backup file
+	Time time.Time // When the backup was taken
+	Size int64
+
+	seq int // Collision counter within the same millisecond; 1 for the first
+}
+
+// BackupRetention returns how many backups to keep, from GH_CONTEXT_SSH_BACKUPS.
+// Zero disables backups.
+func BackupRetention() int {
+	if v := os.Getenv("GH_CONTEXT_SSH_BACKUPS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return DefaultBackupRetention
+}
+
+// BackupDir returns the directory holding backups of the config at configPath.
+func BackupDir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "gh-context-backups")
+}
+
+// ListBackups returns the backups of configPath, newest first.
+func ListBackups(configPath string) ([]Backup, error) {
+	dir := BackupDir(configPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Backup{}, nil
+		}
+		return nil, err
+	}
+
+	prefix := filepath.Base(configPath) + "."
+	var backups []Backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		id := strings.TrimPrefix(name, prefix)
+		timePart, seq := splitBackupID(id)
+		t, err := time.ParseInLocation(backupTimeFormat, timePart, time.Local)
+		if err != nil || seq < 1 {
+			continue // Not one of ours
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{
+			ID:   id,
+			Path: filepath.Join(dir, name),
+			Time: t,
+			Size: info.Size(),
+			seq:  seq,
+		})
+	}
+
+	// Compare counters numerically: "-10" is newer than "-9"
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].Time.Equal(backups[j].Time) {
+			return backups[i].Time.After(backups[j].Time)
+		}
+		return backups[i].seq > backups[j].seq
+	})
+	return backups, nil
+}
+
+// FindBackup returns the backup with the given ID or unique ID prefix.
+// An empty ID selects the most recent backup.
+func FindBackup(configPath, id string) (*Backup, error) {
+	backups, err := ListBackups(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("no backups of %s found", configPath)
+	}
+	if id == "" {
+		return &backups[0], nil
+	}
+
+	var found *Backup
+	for i := range backups {
+		if backups[i].ID == id {
+			return &backups[i], nil
+		}
+		if strings.HasPrefix(backups[i].ID, id) {
+			if found != nil {
+				return nil, fmt.Errorf("backup ID '%s' is ambiguous", id)
+			}
+			found = &backups[i]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("backup '%s' not found", id)
+	}
+	return found, nil
+}
+
+// ReadLines returns the backup content split into lines.
+func (b *Backup) ReadLines() ([]string, error) {
+	data, err := os.ReadFile(b.Path)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfigData(b.Path, data).Lines, nil
+}
+
+// createBackup copies the current config into the backup directory and prunes
+// old backups. Does nothing if the config doesn't exist or retention is zero.
+func createBackup(configPath string) (*Backup, error) {
+	keep := BackupRetention()
+	if keep == 0 {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config for backup: %w", err)
+	}
+
+	dir := BackupDir(configPath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	id := now.Format(backupTimeFormat)
+	path := filepath.Join(dir, filepath.Base(configPath)+"."+id)
+	// Several saves can land in the same millisecond
+	for n := 2; fileExists(path); n++ {
+		id = fmt.Sprintf("%s-%d", now.Format(backupTimeFormat), n)
+		path = filepath.Join(dir, filepath.Base(configPath)+"."+id)
+	}
+
+	if err := fileutil.WriteFileAtomic(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	if err := pruneBackups(configPath, keep); err != nil {
+		return nil, err
+	}
+
+	return &Backup{ID: id, Path: path, Time: now, Size: int64(len(data))}, nil
+}
+
+// pruneBackups deletes all but the newest keep backups.
+func pruneBackups(configPath string, keep int) error {
+	backups, err := ListBackups(configPath)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(backups); i++ {
+		if err := os.Remove(backups[i].Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreBackup replaces the config at configPath with a backup, holding the
+// config lock. The current config is backed up first, so a restore can be undone.
+// Returns the backup taken of the replaced config, if any.
+func RestoreBackup(configPath string, b *Backup) (*Backup, error) {
+	data, err := os.ReadFile(b.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := fileutil.LockFile(configPath + ".lock")
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	previous, err := createBackup(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fileutil.WriteFileAtomic(configPath, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to restore SSH config: %w", err)
+	}
+	return previous, nil
+}
+
+// splitBackupID splits a backup ID into its timestamp and collision counter.
+// IDs without a counter are the first backup of their millisecond. Returns a
+// counter of 0 if the suffix is not a number.
+func splitBackupID(id string) (string, int) {
+	i := strings.LastIndex(id, "-")
+	if i <= len("20060102") {
+		return id, 1
+	}
+	n, err := strconv.Atoi(id[i+1:])
+	if err != nil {
+		return id[:i], 0
+	}
+	return id[:i], n
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
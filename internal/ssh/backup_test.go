@@ -0,0 +1,61 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupsSortCollisionCounterNumerically(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	dir := BackupDir(configPath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	const older, stamp = "20261017-120000.000", "20261017-120001.000"
+	ids := []string{older, stamp}
+	for n := 2; n <= 12; n++ {
+		ids = append(ids, fmt.Sprintf("%s-%d", stamp, n))
+	}
+	for _, id := range ids {
+		if err := os.WriteFile(filepath.Join(dir, "config."+id), []byte(id+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := ListBackups(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != len(ids) {
+		t.Fatalf("listed %d backups, want %d", len(backups), len(ids))
+	}
+	for i, b := range backups {
+		if want := ids[len(ids)-1-i]; b.ID != want {
+			t.Errorf("backups[%d] = %s, want %s", i, b.ID, want)
+		}
+	}
+
+	if err := pruneBackups(configPath, 10); err != nil {
+		t.Fatal(err)
+	}
+	kept, err := ListBackups(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 10 {
+		t.Fatalf("kept %d backups, want 10", len(kept))
+	}
+	// The three oldest go: the earlier second, the uncounted one and "-2"
+	for _, b := range kept {
+		switch b.ID {
+		case older, stamp, stamp + "-2":
+			t.Errorf("pruning kept old backup %s", b.ID)
+		}
+	}
+	if kept[0].ID != stamp+"-12" {
+		t.Errorf("newest backup = %s, want %s-12", kept[0].ID, stamp)
+	}
+}
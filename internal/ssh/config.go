@@ -4,12 +4,14 @@
 package ssh
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/peterjmorgan/gh-context/internal/fileutil"
 )
 
 // DefaultConfigPath returns the default SSH config path.
@@ -21,28 +23,50 @@ func DefaultConfigPath() string {
 	return filepath.Join(home, ".ssh", "config")
 }
 
-// HostBlock represents a Host block in SSH config.
+// BlockKind distinguishes Host blocks from Match blocks.
+type BlockKind int
+
+const (
+	BlockHost  BlockKind = iota // "Host <patterns...>"
+	BlockMatch                  // "Match <criteria...>"
+)
+
+// HostBlock represents a Host or Match block in SSH config.
 type HostBlock struct {
-	StartLine    int      // Line number where "Host X" appears (0-indexed)
-	EndLine      int      // Line number of last line in block (exclusive)
-	Hostname     string   // The hostname pattern from "Host X"
-	Lines        []string // All lines in the block including Host line
+	Kind          BlockKind
+	StartLine     int      // Line number where "Host X" appears (0-indexed)
+	EndLine       int      // Line number of last line in block (exclusive)
+	Hostname      string   // The arguments of the Host/Match line, space-separated
+	Patterns      []string // Host patterns, including "!negated" ones (Host blocks only)
+	Criteria      []string // Match criteria tokens (Match blocks only)
+	Lines         []string // All lines in the block including Host line
+	Directives    []Directive
 	IdentityFiles []IdentityFileLine
+	Managed       bool // Block lives inside the gh-context managed section
 }
 
 // IdentityFileLine represents an IdentityFile line (commented or not).
 type IdentityFileLine struct {
-	LineIndex  int    // Index within HostBlock.Lines
-	Path       string // The path to the key (without ~ expansion)
+	LineIndex   int    // Index within HostBlock.Lines
+	Path        string // The path to the key (without ~ expansion)
 	IsCommented bool
-	FullLine   string // Original line content
+	FullLine    string // Original line content
 }
 
 // ConfigFile represents a parsed SSH config file.
+// Lines hold the file content without line terminators; Save writes them back
+// with the original terminator, so unmodified files round-trip byte-for-byte.
 type ConfigFile struct {
-	Path   string
-	Lines  []string
-	Blocks []HostBlock
+	Path     string
+	Lines    []string
+	Blocks   []HostBlock
+	Preamble []Directive // Directives before the first Host/Match line (apply to all hosts)
+
+	ManagedStart int // Line of the "# BEGIN gh-context" marker, -1 if absent
+	ManagedEnd   int // Line of the "# END gh-context" marker, -1 if absent
+
+	eol          string // "\r\n" if every line ends with CRLF, otherwise "\n"
+	finalNewline bool   // Whether the file ends with a line terminator
 }
 
 // ParseConfig reads and parses an SSH config file.
@@ -51,45 +75,84 @@ func ParseConfig(path string) (*ConfigFile, error) {
 		path = DefaultConfigPath()
 	}
 
-	file, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &ConfigFile{Path: path, Lines: []string{}, Blocks: []HostBlock{}}, nil
+			return parseConfigData(path, nil), nil
 		}
 		return nil, err
 	}
-	defer file.Close()
 
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
+	return parseConfigData(path, data), nil
+}
 
+// parseConfigData parses config content read from path.
+func parseConfigData(path string, data []byte) *ConfigFile {
+	content := string(data)
 	cfg := &ConfigFile{
-		Path:  path,
-		Lines: lines,
+		Path:         path,
+		Lines:        []string{},
+		eol:          "\n",
+		finalNewline: true,
+	}
+
+	if content != "" {
+		cfg.finalNewline = strings.HasSuffix(content, "\n")
+		content = strings.TrimSuffix(content, "\n")
+		cfg.Lines = strings.Split(content, "\n")
+
+		// Only strip CR when the whole file is CRLF; otherwise keep it in the
+		// line so mixed files still round-trip exactly
+		crlf := true
+		for i, line := range cfg.Lines {
+			lastWithoutTerminator := i == len(cfg.Lines)-1 && !cfg.finalNewline
+			if !strings.HasSuffix(line, "\r") && !lastWithoutTerminator {
+				crlf = false
+				break
+			}
+		}
+		if crlf {
+			cfg.eol = "\r\n"
+			for i := range cfg.Lines {
+				cfg.Lines[i] = strings.TrimSuffix(cfg.Lines[i], "\r")
+			}
+		}
 	}
+
 	cfg.parseBlocks()
-	return cfg, nil
+	return cfg
 }
 
-// hostPattern matches "Host <pattern>" lines.
-var hostPattern = regexp.MustCompile(`(?i)^\s*Host\s+(.+?)\s*$`)
-
 // identityFilePattern matches "IdentityFile <path>" lines (commented or not).
-var identityFilePattern = regexp.MustCompile(`(?i)^\s*(#\s*)?(IdentityFile)\s+(.+?)\s*$`)
+var identityFilePattern = regexp.MustCompile(`(?i)^\s*(#\s*)?(IdentityFile)(?:\s*=\s*|\s+)(.+?)\s*$`)
 
 func (c *ConfigFile) parseBlocks() {
 	c.Blocks = nil
+	c.Preamble = nil
+	c.ManagedStart, c.ManagedEnd = -1, -1
 
 	var currentBlock *HostBlock
+	inManaged := false
 
 	for i, line := range c.Lines {
-		if match := hostPattern.FindStringSubmatch(line); match != nil {
+		if isManagedBegin(line) && c.ManagedStart < 0 {
+			c.ManagedStart = i
+			inManaged = true
+		}
+		if isManagedEnd(line) && inManaged {
+			// The managed section ends here; don't let its last block swallow what follows
+			c.ManagedEnd = i
+			inManaged = false
+			if currentBlock != nil {
+				currentBlock.EndLine = i
+				c.Blocks = append(c.Blocks, *currentBlock)
+				currentBlock = nil
+			}
+			continue
+		}
+
+		keyword, args, ok := tokenizeLine(line)
+		if ok && (keyword == "host" || keyword == "match") {
 			// Save previous block
 			if currentBlock != nil {
 				currentBlock.EndLine = i
@@ -98,23 +161,48 @@ func (c *ConfigFile) parseBlocks() {
 			// Start new block
 			currentBlock = &HostBlock{
 				StartLine: i,
-				Hostname:  strings.TrimSpace(match[1]),
+				Hostname:  strings.Join(args, " "),
 				Lines:     []string{line},
+				Managed:   inManaged,
 			}
-		} else if currentBlock != nil {
-			// Add line to current block
-			currentBlock.Lines = append(currentBlock.Lines, line)
-
-			// Check for IdentityFile
-			if match := identityFilePattern.FindStringSubmatch(line); match != nil {
-				ifl := IdentityFileLine{
-					LineIndex:   len(currentBlock.Lines) - 1,
-					IsCommented: match[1] != "",
-					Path:        strings.TrimSpace(match[3]),
-					FullLine:    line,
-				}
-				currentBlock.IdentityFiles = append(currentBlock.IdentityFiles, ifl)
+			if keyword == "match" {
+				currentBlock.Kind = BlockMatch
+				currentBlock.Criteria = args
+			} else {
+				currentBlock.Kind = BlockHost
+				currentBlock.Patterns = args
+			}
+			continue
+		}
+
+		if currentBlock == nil {
+			// Global directive before the first block
+			if ok {
+				c.Preamble = append(c.Preamble, Directive{File: c.Path, Line: i, Keyword: keyword, Args: args})
 			}
+			continue
+		}
+
+		// Add line to current block
+		currentBlock.Lines = append(currentBlock.Lines, line)
+
+		if ok {
+			currentBlock.Directives = append(currentBlock.Directives,
+				Directive{File: c.Path, Line: i, Keyword: keyword, Args: args})
+		}
+
+		// Track IdentityFile lines, including commented-out ones
+		commented := false
+		if !ok {
+			keyword, args, commented = tokenizeCommentedLine(line)
+		}
+		if keyword == "identityfile" && len(args) > 0 {
+			currentBlock.IdentityFiles = append(currentBlock.IdentityFiles, IdentityFileLine{
+				LineIndex:   len(currentBlock.Lines) - 1,
+				IsCommented: commented,
+				Path:        args[0],
+				FullLine:    line,
+			})
 		}
 	}
 
@@ -123,31 +211,52 @@ func (c *ConfigFile) parseBlocks() {
 		currentBlock.EndLine = len(c.Lines)
 		c.Blocks = append(c.Blocks, *currentBlock)
 	}
+
+	// Without an END marker there is no telling where the section stops, so
+	// none of the blocks are treated as ours (see ErrManagedUnterminated)
+	if inManaged {
+		for i := range c.Blocks {
+			c.Blocks[i].Managed = false
+		}
+	}
 }
 
-// FindHostBlock finds a Host block by hostname.
+// FindHostBlock finds the user-owned Host block in this file that configures hostname.
+// A block naming the host literally (e.g., "Host github.com gist.github.com") is
+// preferred; otherwise the first block whose patterns match the host and that has
+// IdentityFile lines is used. Match blocks, included files and the managed alias
+// section are never returned, since they are not edited.
 func (c *ConfigFile) FindHostBlock(hostname string) *HostBlock {
+	host := strings.ToLower(hostname)
+
 	for i := range c.Blocks {
-		if c.Blocks[i].Hostname == hostname {
-			return &c.Blocks[i]
+		b := &c.Blocks[i]
+		if b.Managed || b.Kind != BlockHost || !matchHostPatterns(host, b.Patterns) {
+			continue
+		}
+		for _, p := range b.Patterns {
+			if !hasWildcard(p) && strings.ToLower(p) == host {
+				return b
+			}
+		}
+	}
+
+	for i := range c.Blocks {
+		b := &c.Blocks[i]
+		if b.Managed || b.Kind != BlockHost || len(b.IdentityFiles) == 0 {
+			continue
+		}
+		if matchHostPatterns(host, b.Patterns) {
+			return b
 		}
 	}
 	return nil
 }
 
-// GetActiveIdentityFile returns the currently active (uncommented) IdentityFile for a host.
+// GetActiveIdentityFile returns the IdentityFile ssh will try first for a host,
+// evaluating every Host, Match and Include that applies.
 func (c *ConfigFile) GetActiveIdentityFile(hostname string) string {
-	block := c.FindHostBlock(hostname)
-	if block == nil {
-		return ""
-	}
-
-	for _, ifl := range block.IdentityFiles {
-		if !ifl.IsCommented {
-			return ifl.Path
-		}
-	}
-	return ""
+	return c.Get(hostname, "IdentityFile")
 }
 
 // ActivateKey activates a specific SSH key for a hostname by:
@@ -196,11 +305,15 @@ func (c *ConfigFile) ActivateKey(hostname, keyPath string) error {
 }
 
 // AddIdentityFile adds a new IdentityFile line to a Host block.
-// If the block doesn't exist, returns an error.
+// If the block doesn't exist, a "Host <hostname>" block is appended for it.
 func (c *ConfigFile) AddIdentityFile(hostname, keyPath string, active bool) error {
 	block := c.FindHostBlock(hostname)
 	if block == nil {
-		return fmt.Errorf("no Host block found for '%s' in SSH config", hostname)
+		c.appendHostBlock(hostname)
+		block = c.FindHostBlock(hostname)
+		if block == nil {
+			return fmt.Errorf("failed to add a Host block for '%s' to SSH config", hostname)
+		}
 	}
 
 	// Check if it already exists
@@ -215,9 +328,9 @@ func (c *ConfigFile) AddIdentityFile(hostname, keyPath string, active bool) erro
 	indent := detectIndent(block.Lines)
 	var newLine string
 	if active {
-		newLine = fmt.Sprintf("%sIdentityFile %s", indent, keyPath)
+		newLine = fmt.Sprintf("%sIdentityFile %s", indent, quoteArg(keyPath))
 	} else {
-		newLine = fmt.Sprintf("%s# IdentityFile %s", indent, keyPath)
+		newLine = fmt.Sprintf("%s# IdentityFile %s", indent, quoteArg(keyPath))
 	}
 
 	// Find insertion point (after last IdentityFile, or after Host line)
@@ -235,33 +348,84 @@ func (c *ConfigFile) AddIdentityFile(hostname, keyPath string, active bool) erro
 	return nil
 }
 
-// Save writes the config back to disk, creating a backup first.
+// appendHostBlock adds an empty "Host <hostname>" block at the end of the file.
+func (c *ConfigFile) appendHostBlock(hostname string) {
+	if n := len(c.Lines); n > 0 && strings.TrimSpace(c.Lines[n-1]) != "" {
+		c.Lines = append(c.Lines, "")
+	}
+	c.Lines = append(c.Lines, "Host "+hostname)
+	c.finalNewline = true
+	c.parseBlocks()
+}
+
+// Save writes the config back to disk, taking a timestamped backup of the
+// previous version first (see ListBackups). Nothing is written if the content
+// is unchanged. Files are replaced atomically; use Update to also hold the
+// config lock across the read-modify-write cycle.
 func (c *ConfigFile) Save() error {
-	// Create backup
-	backupPath := c.Path + ".bak"
-	if _, err := os.Stat(c.Path); err == nil {
-		data, err := os.ReadFile(c.Path)
-		if err != nil {
-			return fmt.Errorf("failed to read config for backup: %w", err)
-		}
-		if err := os.WriteFile(backupPath, data, 0600); err != nil {
-			return fmt.Errorf("failed to create backup: %w", err)
-		}
+	content := c.Bytes()
+
+	if current, err := os.ReadFile(c.Path); err == nil && bytes.Equal(current, content) {
+		return nil
 	}
 
-	// Write new config
-	content := strings.Join(c.Lines, "\n")
-	if len(c.Lines) > 0 && !strings.HasSuffix(content, "\n") {
-		content += "\n"
+	if _, err := createBackup(c.Path); err != nil {
+		return err
 	}
 
-	if err := os.WriteFile(c.Path, []byte(content), 0600); err != nil {
+	// Write new config
+	if err := fileutil.WriteFileAtomic(c.Path, content, 0600); err != nil {
 		return fmt.Errorf("failed to write SSH config: %w", err)
 	}
 
 	return nil
 }
 
+// Bytes renders the config with its original line terminators.
+func (c *ConfigFile) Bytes() []byte {
+	if len(c.Lines) == 0 {
+		return nil
+	}
+	content := strings.Join(c.Lines, c.eol)
+	if c.finalNewline {
+		content += c.eol
+	}
+	return []byte(content)
+}
+
+// Update parses the SSH config at path while holding an exclusive lock on it,
+// calls fn, and saves the result if fn reports a change. Concurrent updates from
+// other gh context processes are serialized.
+func Update(path string, fn func(c *ConfigFile) (bool, error)) error {
+	if path == "" {
+		path = DefaultConfigPath()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	lock, err := fileutil.LockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	cfg, err := ParseConfig(path)
+	if err != nil {
+		return err
+	}
+
+	changed, err := fn(cfg)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	return cfg.Save()
+}
+
 // Helper functions
 
 func normalizePath(p string) string {
@@ -0,0 +1,145 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"empty", ""},
+		{"single newline", "\n"},
+		{"lf", "Host github.com\n  IdentityFile ~/.ssh/id_ed25519\n"},
+		{"crlf", "Host github.com\r\n  IdentityFile ~/.ssh/id_ed25519\r\n"},
+		{"mixed terminators", "Host github.com\r\n  IdentityFile ~/.ssh/id_ed25519\n"},
+		{"no final newline", "Host github.com\n  IdentityFile ~/.ssh/id_ed25519"},
+		{"crlf without final newline", "Host github.com\r\n  IdentityFile ~/.ssh/id_ed25519"},
+		{"comments and blank lines", "# my config\n\nHost github.com # main\n\t# IdentityFile ~/.ssh/old\n\tIdentityFile ~/.ssh/id_ed25519\n\n\n"},
+		{"equals syntax", "Host=github.com\nIdentityFile = ~/.ssh/id_ed25519\nUser=git\n"},
+		{"trailing whitespace", "Host github.com  \n  IdentityFile ~/.ssh/id_ed25519\t\n"},
+		{"preamble, match and include", "Include conf.d/*\nAddKeysToAgent yes\n\nMatch host *.example.com\n  User me\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := parseConfigData("config", []byte(tt.content))
+			if got := string(cfg.Bytes()); got != tt.content {
+				t.Errorf("Bytes() = %q, want %q", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestEditKeepsLineTerminators(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "crlf",
+			content: "Host github.com\r\n  IdentityFile ~/.ssh/id_personal\r\n  # IdentityFile ~/.ssh/id_work\r\n",
+			want:    "Host github.com\r\n  # IdentityFile ~/.ssh/id_personal\r\n  IdentityFile ~/.ssh/id_work\r\n",
+		},
+		{
+			name:    "no final newline",
+			content: "Host github.com\n  IdentityFile ~/.ssh/id_personal\n  # IdentityFile ~/.ssh/id_work",
+			want:    "Host github.com\n  # IdentityFile ~/.ssh/id_personal\n  IdentityFile ~/.ssh/id_work",
+		},
+		{
+			// Toggled lines are rewritten in the usual form; the rest stay as they were
+			name:    "equals syntax",
+			content: "Host=github.com\n  User = git\n  IdentityFile=~/.ssh/id_personal\n  #IdentityFile = ~/.ssh/id_work\n",
+			want:    "Host=github.com\n  User = git\n  # IdentityFile ~/.ssh/id_personal\n  IdentityFile ~/.ssh/id_work\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := parseConfigData("config", []byte(tt.content))
+			if err := cfg.ActivateKey("github.com", "~/.ssh/id_work"); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(cfg.Bytes()); got != tt.want {
+				t.Errorf("after ActivateKey: %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcurrentUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	initial := "Host github.com\n  IdentityFile ~/.ssh/id_ed25519\n"
+	if err := os.WriteFile(path, []byte(initial), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, rounds = 8, 5
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Errorf("read during writes: %v", err)
+				return
+			}
+			if !strings.HasPrefix(string(data), initial) || !strings.HasSuffix(string(data), "\n") {
+				t.Errorf("reader saw truncated config: %q", data)
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				host := fmt.Sprintf("host-%d-%d", w, r)
+				err := Update(path, func(c *ConfigFile) (bool, error) {
+					c.appendHostBlock(host)
+					return true, nil
+				})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	cfg, err := ParseConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(cfg.Blocks); got != 1+workers*rounds {
+		t.Errorf("config has %d blocks, want %d (updates were lost)", got, 1+workers*rounds)
+	}
+	for w := 0; w < workers; w++ {
+		for r := 0; r < rounds; r++ {
+			host := fmt.Sprintf("host-%d-%d", w, r)
+			if cfg.FindHostBlock(host) == nil {
+				t.Errorf("Host %s is missing", host)
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// ABOUTME: SSH key generation for gh-context
+// ABOUTME: Creates ed25519 keypairs with ssh-keygen for new contexts
+
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// DefaultKeyPath returns the conventional key path for a context, as a
+// ~/.ssh/... path so it reads the same in contexts and ~/.ssh/config.
+func DefaultKeyPath(name string) string {
+	return "~/.ssh/id_" + name
+}
+
+// GenerateKey creates an ed25519 keypair at keyPath with ssh-keygen. When
+// interactive, ssh-keygen asks for a passphrase on the terminal; otherwise the
+// key is created without one. Existing keys are never overwritten.
+func GenerateKey(keyPath, comment string, interactive bool) error {
+	path := ExpandPath(keyPath)
+	for _, p := range []string{path, path + ".pub"} {
+		if _, err := os.Stat(p); err == nil {
+			return fmt.Errorf("%s already exists; use it without --generate-key", p)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	args := []string{"-q", "-t", "ed25519", "-f", path, "-C", comment}
+	if !interactive {
+		args = append(args, "-N", "")
+	}
+	cmd := exec.Command("ssh-keygen", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ssh-keygen failed: %w", err)
+	}
+
+	// ssh-keygen already does this; make sure regardless of umask
+	if err := os.Chmod(path, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path+".pub", 0644)
+}
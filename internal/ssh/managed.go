@@ -0,0 +1,187 @@
+// ABOUTME: Managed Host alias section of ~/.ssh/config for gh-context
+// ABOUTME: Maintains a fenced block with one Host <hostname>-<context> alias per context
+
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	managedBegin = "# BEGIN gh-context"
+	managedEnd   = "# END gh-context"
+)
+
+// ErrManagedUnterminated is returned when changing aliases in a config whose
+// "# BEGIN gh-context" marker has no matching "# END gh-context" line.
+var ErrManagedUnterminated = errors.New("managed section has no '" + managedEnd + "' line")
+
+// ManagedAlias is a Host alias block owned by gh-context.
+type ManagedAlias struct {
+	Alias        string // Host alias (e.g., github.com-work)
+	Hostname     string // Real hostname the alias connects to
+	IdentityFile string // Key used for the alias
+}
+
+// AliasName returns the SSH Host alias used for a context on a hostname.
+func AliasName(hostname, contextName string) string {
+	return hostname + "-" + contextName
+}
+
+// isManagedBegin reports whether a line opens the managed section.
+func isManagedBegin(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), managedBegin)
+}
+
+// isManagedEnd reports whether a line closes the managed section.
+func isManagedEnd(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), managedEnd)
+}
+
+// ManagedAliases returns the alias blocks in the managed section, in file order.
+func (c *ConfigFile) ManagedAliases() []ManagedAlias {
+	var aliases []ManagedAlias
+	for _, block := range c.Blocks {
+		if !block.Managed {
+			continue
+		}
+
+		alias := ManagedAlias{Alias: block.Hostname}
+		for _, d := range block.Directives {
+			if d.Keyword == "hostname" && len(d.Args) > 0 {
+				alias.Hostname = d.Args[0]
+			}
+		}
+		for _, ifl := range block.IdentityFiles {
+			if !ifl.IsCommented {
+				alias.IdentityFile = ifl.Path
+				break
+			}
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// FindAlias returns the managed alias with the given name, or nil.
+func (c *ConfigFile) FindAlias(alias string) *ManagedAlias {
+	for _, a := range c.ManagedAliases() {
+		if a.Alias == alias {
+			return &a
+		}
+	}
+	return nil
+}
+
+// SetAlias adds or replaces a managed alias block.
+// Returns true if the config changed.
+func (c *ConfigFile) SetAlias(alias, hostname, keyPath string) (bool, error) {
+	if err := c.checkManaged(); err != nil {
+		return false, err
+	}
+	want := ManagedAlias{Alias: alias, Hostname: hostname, IdentityFile: keyPath}
+
+	aliases := c.ManagedAliases()
+	for i, a := range aliases {
+		if a.Alias == alias {
+			if a == want {
+				return false, nil
+			}
+			aliases[i] = want
+			c.writeManaged(aliases)
+			return true, nil
+		}
+	}
+
+	c.writeManaged(append(aliases, want))
+	return true, nil
+}
+
+// RemoveAlias deletes a managed alias block.
+// Returns true if the alias existed.
+func (c *ConfigFile) RemoveAlias(alias string) (bool, error) {
+	if err := c.checkManaged(); err != nil {
+		return false, err
+	}
+
+	aliases := c.ManagedAliases()
+	for i, a := range aliases {
+		if a.Alias == alias {
+			c.writeManaged(append(aliases[:i], aliases[i+1:]...))
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkManaged refuses to rewrite a managed section whose end is unknown:
+// splicing it would duplicate or drop the user's own config.
+func (c *ConfigFile) checkManaged() error {
+	if c.ManagedStart >= 0 && c.ManagedEnd < 0 {
+		return fmt.Errorf("%s: %w; restore it after the gh-context aliases (line %d starts the section)",
+			c.Path, ErrManagedUnterminated, c.ManagedStart+1)
+	}
+	return nil
+}
+
+// writeManaged replaces the managed section with the given aliases.
+// The section is removed entirely when no aliases remain. The caller checks
+// the section is well-formed first (see checkManaged).
+func (c *ConfigFile) writeManaged(aliases []ManagedAlias) {
+	var section []string
+	if len(aliases) > 0 {
+		section = renderManaged(aliases)
+	}
+
+	if c.ManagedStart >= 0 {
+		end := c.ManagedEnd + 1
+		// Drop the blank separator we added after the section
+		if len(section) == 0 && end < len(c.Lines) && strings.TrimSpace(c.Lines[end]) == "" {
+			end++
+		}
+		c.Lines = spliceLines(c.Lines, c.ManagedStart, end, section)
+	} else if len(section) > 0 {
+		// Insert before the first Host/Match block so global options stay global
+		// and our aliases win over catch-all blocks further down
+		insertIdx := len(c.Lines)
+		if len(c.Blocks) > 0 {
+			insertIdx = c.Blocks[0].StartLine
+		}
+		if insertIdx < len(c.Lines) {
+			section = append(section, "")
+		} else if insertIdx > 0 && strings.TrimSpace(c.Lines[insertIdx-1]) != "" {
+			section = append([]string{""}, section...)
+		}
+		c.Lines = spliceLines(c.Lines, insertIdx, insertIdx, section)
+	}
+
+	c.parseBlocks()
+}
+
+// renderManaged produces the lines of the managed section.
+func renderManaged(aliases []ManagedAlias) []string {
+	lines := []string{managedBegin + " (generated by gh-context, do not edit by hand)"}
+	for i, a := range aliases {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines,
+			"Host "+a.Alias,
+			"    HostName "+a.Hostname,
+			"    User git",
+			"    IdentityFile "+quoteArg(a.IdentityFile),
+			"    IdentitiesOnly yes",
+		)
+	}
+	return append(lines, managedEnd)
+}
+
+// spliceLines replaces lines[start:end] with repl.
+func spliceLines(lines []string, start, end int, repl []string) []string {
+	out := make([]string, 0, len(lines)-(end-start)+len(repl))
+	out = append(out, lines[:start]...)
+	out = append(out, repl...)
+	return append(out, lines[end:]...)
+}
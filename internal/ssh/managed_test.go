@@ -0,0 +1,61 @@
+package ssh
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const unterminatedConfig = `Host *
+    AddKeysToAgent yes
+
+# BEGIN gh-context (generated by gh-context, do not edit by hand)
+Host github.com-work
+    HostName github.com
+    User git
+    IdentityFile ~/.ssh/id_work
+    IdentitiesOnly yes
+
+Host github.com
+    IdentityFile ~/.ssh/id_personal
+`
+
+func TestUnterminatedManagedSection(t *testing.T) {
+	cfg := parseConfigData("config", []byte(unterminatedConfig))
+
+	for _, b := range cfg.Blocks {
+		if b.Managed {
+			t.Errorf("block 'Host %s' is marked managed without an END marker", b.Hostname)
+		}
+	}
+	if cfg.FindHostBlock("github.com") == nil {
+		t.Error("user's 'Host github.com' block is hidden by the broken section")
+	}
+
+	if _, err := cfg.SetAlias("github.com-home", "github.com", "~/.ssh/id_home"); !errors.Is(err, ErrManagedUnterminated) {
+		t.Errorf("SetAlias error = %v, want ErrManagedUnterminated", err)
+	}
+	if _, err := cfg.RemoveAlias("github.com-work"); !errors.Is(err, ErrManagedUnterminated) {
+		t.Errorf("RemoveAlias error = %v, want ErrManagedUnterminated", err)
+	}
+	if got := string(cfg.Bytes()); got != unterminatedConfig {
+		t.Errorf("config changed after refused edits:\n%s", got)
+	}
+}
+
+func TestSetAliasReplacesTerminatedSection(t *testing.T) {
+	data := strings.Replace(unterminatedConfig, "\nHost github.com\n", "# END gh-context\n\nHost github.com\n", 1)
+	cfg := parseConfigData("config", []byte(data))
+
+	changed, err := cfg.SetAlias("github.com-work", "github.com", "~/.ssh/id_work2")
+	if err != nil || !changed {
+		t.Fatalf("SetAlias = %v, %v; want a change", changed, err)
+	}
+	got := string(cfg.Bytes())
+	if n := strings.Count(got, "Host github.com\n"); n != 1 {
+		t.Errorf("'Host github.com' appears %d times:\n%s", n, got)
+	}
+	if a := cfg.FindAlias("github.com-work"); a == nil || a.IdentityFile != "~/.ssh/id_work2" {
+		t.Errorf("alias = %+v, want IdentityFile ~/.ssh/id_work2", a)
+	}
+}
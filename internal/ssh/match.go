@@ -0,0 +1,304 @@
+// ABOUTME: Host/Match evaluation for SSH config files
+// ABOUTME: Resolves which directives apply to a host, following Include like OpenSSH
+
+package ssh
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxIncludeDepth mirrors OpenSSH's READCONF_MAX_DEPTH.
+const maxIncludeDepth = 16
+
+// multiValueKeywords accumulate across blocks instead of first-match-wins.
+var multiValueKeywords = map[string]bool{
+	"identityfile":    true,
+	"certificatefile": true,
+	"localforward":    true,
+	"remoteforward":   true,
+	"dynamicforward":  true,
+	"sendenv":         true,
+	"setenv":          true,
+}
+
+// evalState carries what ssh knows while reading config for one connection.
+type evalState struct {
+	host      string // Host as given on the command line (lowercased)
+	hostname  string // First HostName seen, used by "Match host"
+	user      string // First User seen, used by "Match user"
+	localUser string
+	out       []Directive
+	included  map[string]*ConfigFile
+}
+
+// Evaluate returns the directives that apply when connecting to host, in the
+// order ssh reads them, following Include files. For most keywords the first
+// occurrence wins; IdentityFile and the other multi-value keywords accumulate.
+//
+// Match criteria that need runtime information (exec, canonical, final,
+// localnetwork, tagged) are treated as not matching.
+func (c *ConfigFile) Evaluate(host string) []Directive {
+	st := &evalState{
+		host:     strings.ToLower(host),
+		included: make(map[string]*ConfigFile),
+	}
+	if u, err := user.Current(); err == nil {
+		st.localUser = u.Username
+	}
+
+	c.evaluate(st, 0)
+	return st.out
+}
+
+// Get returns the effective value of keyword for host, or "" if unset.
+func (c *ConfigFile) Get(host, keyword string) string {
+	keyword = strings.ToLower(keyword)
+	for _, d := range c.Evaluate(host) {
+		if d.Keyword == keyword {
+			return d.Value()
+		}
+	}
+	return ""
+}
+
+// GetAll returns every value of keyword that applies to host, in order.
+// For single-value keywords only the first one is effective.
+func (c *ConfigFile) GetAll(host, keyword string) []string {
+	keyword = strings.ToLower(keyword)
+	var values []string
+	for _, d := range c.Evaluate(host) {
+		if d.Keyword == keyword {
+			values = append(values, d.Value())
+		}
+	}
+	return values
+}
+
+// IsMultiValue reports whether a keyword accumulates values across blocks.
+func IsMultiValue(keyword string) bool {
+	return multiValueKeywords[strings.ToLower(keyword)]
+}
+
+func (c *ConfigFile) evaluate(st *evalState, depth int) {
+	c.evalDirectives(st, c.Preamble, depth)
+	for i := range c.Blocks {
+		if st.blockMatches(&c.Blocks[i]) {
+			c.evalDirectives(st, c.Blocks[i].Directives, depth)
+		}
+	}
+}
+
+func (c *ConfigFile) evalDirectives(st *evalState, directives []Directive, depth int) {
+	for _, d := range directives {
+		switch d.Keyword {
+		case "include":
+			if depth >= maxIncludeDepth {
+				continue
+			}
+			for _, inc := range c.includedFiles(st, d.Args) {
+				inc.evaluate(st, depth+1)
+			}
+			continue
+		case "hostname":
+			if st.hostname == "" && len(d.Args) > 0 {
+				st.hostname = strings.ToLower(strings.ReplaceAll(d.Args[0], "%h", st.host))
+			}
+		case "user":
+			if st.user == "" && len(d.Args) > 0 {
+				st.user = d.Args[0]
+			}
+		}
+		st.out = append(st.out, d)
+	}
+}
+
+// includedFiles resolves Include arguments to parsed files. Like OpenSSH for
+// user configs, relative paths are relative to ~/.ssh, whichever file the
+// Include is in.
+func (c *ConfigFile) includedFiles(st *evalState, patterns []string) []*ConfigFile {
+	var files []*ConfigFile
+	for _, pattern := range patterns {
+		pattern = ExpandPath(pattern)
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(userSSHDir(), pattern)
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
+		}
+		sort.Strings(matches)
+
+		for _, path := range matches {
+			inc, ok := st.included[path]
+			if !ok {
+				inc, err = readConfigFile(path)
+				if err != nil {
+					inc = nil // Unreadable includes are skipped, like missing ones
+				}
+				st.included[path] = inc
+			}
+			if inc != nil {
+				files = append(files, inc)
+			}
+		}
+	}
+	return files
+}
+
+// userSSHDir returns ~/.ssh, the base of relative Include paths.
+func userSSHDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(home, ".ssh")
+}
+
+// blockMatches reports whether a Host or Match block applies.
+func (st *evalState) blockMatches(b *HostBlock) bool {
+	if b.Kind == BlockMatch {
+		return st.matchCriteria(b.Criteria)
+	}
+	return matchHostPatterns(st.host, b.Patterns)
+}
+
+// matchCriteria evaluates the criteria of a Match line; all must match.
+func (st *evalState) matchCriteria(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(args); i++ {
+		criterion := strings.ToLower(args[i])
+		negate := strings.HasPrefix(criterion, "!")
+		criterion = strings.TrimPrefix(criterion, "!")
+
+		var result bool
+		switch criterion {
+		case "all":
+			result = true
+		case "canonical", "final":
+			result = false // Only true on ssh's canonicalization/final passes
+		case "host", "originalhost", "user", "localuser", "exec", "localnetwork", "tagged":
+			if i+1 >= len(args) {
+				return false
+			}
+			i++
+			arg := args[i]
+
+			switch criterion {
+			case "host":
+				result = matchPatternList(st.effectiveHost(), arg)
+			case "originalhost":
+				result = matchPatternList(st.host, arg)
+			case "user":
+				result = matchPatternList(st.effectiveUser(), arg)
+			case "localuser":
+				result = matchPatternList(st.localUser, arg)
+			default:
+				result = false // Needs a command, network or tag at runtime
+			}
+		default:
+			return false // Unknown criterion; ssh refuses the config
+		}
+
+		if negate {
+			result = !result
+		}
+		if !result {
+			return false
+		}
+	}
+	return true
+}
+
+func (st *evalState) effectiveHost() string {
+	if st.hostname != "" {
+		return st.hostname
+	}
+	return st.host
+}
+
+// effectiveUser defaults to "git", the user in GitHub SSH remote URLs.
+func (st *evalState) effectiveUser() string {
+	if st.user != "" {
+		return st.user
+	}
+	return "git"
+}
+
+// matchHostPatterns applies "Host" pattern semantics: the host must match at
+// least one pattern and none of the negated ("!pattern") ones.
+func matchHostPatterns(host string, patterns []string) bool {
+	host = strings.ToLower(host)
+	matched := false
+	for _, p := range patterns {
+		if strings.HasPrefix(p, "!") {
+			if matchGlob(host, strings.ToLower(p[1:])) {
+				return false
+			}
+			continue
+		}
+		if matchGlob(host, strings.ToLower(p)) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchPatternList applies a comma-separated pattern list, as used by Match.
+func matchPatternList(s, list string) bool {
+	return matchHostPatterns(s, strings.Split(list, ","))
+}
+
+// matchGlob matches s against a pattern with '*' and '?' wildcards.
+func matchGlob(s, pattern string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// Collapse consecutive stars, then try every suffix
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchGlob(s[i:], pattern) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if s == "" {
+				return false
+			}
+		default:
+			if s == "" || s[0] != pattern[0] {
+				return false
+			}
+		}
+		s = s[1:]
+		pattern = pattern[1:]
+	}
+	return s == ""
+}
+
+// hasWildcard reports whether a Host pattern contains wildcards or negation.
+func hasWildcard(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?!")
+}
+
+// readConfigFile parses an included file; missing files are an error here.
+func readConfigFile(path string) (*ConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfigData(path, data), nil
+}
@@ -0,0 +1,277 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatchHostPatterns(t *testing.T) {
+	tests := []struct {
+		host     string
+		patterns []string
+		want     bool
+	}{
+		{"github.com", []string{"github.com"}, true},
+		{"GitHub.com", []string{"github.com"}, true},
+		{"github.com", []string{"GITHUB.COM"}, true},
+		{"github.com", []string{"gitlab.com"}, false},
+		{"github.com", []string{"*"}, true},
+		{"gist.github.com", []string{"*.github.com"}, true},
+		{"github.com", []string{"*.github.com"}, false},
+		{"github.com", []string{"git?ub.com"}, true},
+		{"github.com", []string{"git?.com"}, false},
+		{"github.com", []string{"gitlab.com", "github.com"}, true},
+		{"github.com", []string{"*", "!github.com"}, false},
+		{"gist.github.com", []string{"*", "!github.com"}, true},
+		{"ghe.corp", []string{"!*.github.com", "*"}, true},
+		{"gist.github.com", []string{"!*.github.com", "*"}, false},
+		{"github.com", []string{"!gitlab.com"}, false}, // Negation alone never matches
+		{"github.com", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %v", tt.host, tt.patterns), func(t *testing.T) {
+			if got := matchHostPatterns(tt.host, tt.patterns); got != tt.want {
+				t.Errorf("matchHostPatterns(%q, %q) = %v, want %v", tt.host, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		host   string
+		want   []string // IdentityFile values in order
+	}{
+		{
+			name:   "host block",
+			config: "Host github.com\n  IdentityFile a\nHost gitlab.com\n  IdentityFile b\n",
+			host:   "github.com",
+			want:   []string{"a"},
+		},
+		{
+			name:   "identity files accumulate across blocks",
+			config: "Host github.com\n  IdentityFile a\nHost *\n  IdentityFile b\n",
+			host:   "github.com",
+			want:   []string{"a", "b"},
+		},
+		{
+			name:   "preamble applies to every host",
+			config: "IdentityFile a\nHost github.com\n  IdentityFile b\n",
+			host:   "github.com",
+			want:   []string{"a", "b"},
+		},
+		{
+			name:   "negated host pattern",
+			config: "Host * !github.com\n  IdentityFile a\nHost *\n  IdentityFile b\n",
+			host:   "github.com",
+			want:   []string{"b"},
+		},
+		{
+			name:   "match all",
+			config: "Match all\n  IdentityFile a\n",
+			host:   "github.com",
+			want:   []string{"a"},
+		},
+		{
+			name:   "match host uses HostName",
+			config: "Host gh-work\n  HostName github.com\nMatch host github.com\n  IdentityFile a\n",
+			host:   "gh-work",
+			want:   []string{"a"},
+		},
+		{
+			name:   "match host expands %h",
+			config: "Host gh\n  HostName %h.example.com\nMatch host gh.example.com\n  IdentityFile a\n",
+			host:   "gh",
+			want:   []string{"a"},
+		},
+		{
+			name:   "match originalhost ignores HostName",
+			config: "Host gh-work\n  HostName github.com\nMatch originalhost github.com\n  IdentityFile a\nMatch originalhost gh-*\n  IdentityFile b\n",
+			host:   "gh-work",
+			want:   []string{"b"},
+		},
+		{
+			name:   "match user defaults to git",
+			config: "Match user git\n  IdentityFile a\nMatch user someone\n  IdentityFile b\n",
+			host:   "github.com",
+			want:   []string{"a"},
+		},
+		{
+			name:   "match user from User",
+			config: "Host github.com\n  User someone\nMatch user someone\n  IdentityFile a\n",
+			host:   "github.com",
+			want:   []string{"a"},
+		},
+		{
+			name:   "negated criterion",
+			config: "Match !host github.com\n  IdentityFile a\nMatch !host gitlab.com\n  IdentityFile b\n",
+			host:   "github.com",
+			want:   []string{"b"},
+		},
+		{
+			name:   "pattern lists",
+			config: "Match host gitlab.com,*.github.com,github.com\n  IdentityFile a\nMatch host *,!github.com\n  IdentityFile b\n",
+			host:   "github.com",
+			want:   []string{"a"},
+		},
+		{
+			name:   "all criteria must match",
+			config: "Match host github.com user someone\n  IdentityFile a\nMatch host github.com user git\n  IdentityFile b\n",
+			host:   "github.com",
+			want:   []string{"b"},
+		},
+		{
+			name:   "runtime criteria never match",
+			config: "Match exec true\n  IdentityFile a\nMatch canonical\n  IdentityFile b\nMatch final all\n  IdentityFile c\nMatch !exec true\n  IdentityFile d\n",
+			host:   "github.com",
+			want:   []string{"d"},
+		},
+		{
+			name:   "unknown criterion or missing argument",
+			config: "Match bogus\n  IdentityFile a\nMatch host\n  IdentityFile b\nMatch\n  IdentityFile c\n",
+			host:   "github.com",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := parseConfigData("config", []byte(tt.config))
+			if got := cfg.GetAll(tt.host, "IdentityFile"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetAll(%q, IdentityFile) = %q, want %q", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateFirstValueWins(t *testing.T) {
+	cfg := parseConfigData("config", []byte("Host github.com\n  User first\nHost *\n  User second\n  Port 2222\n"))
+	if got := cfg.Get("github.com", "User"); got != "first" {
+		t.Errorf("Get(User) = %q, want first", got)
+	}
+	if got := cfg.Get("github.com", "Port"); got != "2222" {
+		t.Errorf("Get(Port) = %q, want 2222", got)
+	}
+	if got := cfg.Get("github.com", "ProxyJump"); got != "" {
+		t.Errorf("Get(ProxyJump) = %q, want empty", got)
+	}
+}
+
+// writeSSHFile writes a file below home/.ssh and returns its path.
+func writeSSHFile(t *testing.T, home, name, content string) string {
+	t.Helper()
+	path := filepath.Join(home, ".ssh", name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEvaluateInclude(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	writeSSHFile(t, home, "conf.d/10-work", "Host github.com\n  IdentityFile work\n")
+	writeSSHFile(t, home, "conf.d/20-nested", "Include nested\n")
+	writeSSHFile(t, home, "conf.d/nested", "Host github.com\n  IdentityFile wrong-dir\n")
+	writeSSHFile(t, home, "nested", "Host github.com\n  IdentityFile nested\n")
+	writeSSHFile(t, home, "abs", "Host github.com\n  IdentityFile absolute\n")
+
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{
+			name:   "relative glob, sorted, nested include relative to ~/.ssh",
+			config: "Include conf.d/[0-9]*\n",
+			want:   []string{"work", "nested"},
+		},
+		{
+			name:   "tilde path",
+			config: "Include ~/.ssh/abs\n",
+			want:   []string{"absolute"},
+		},
+		{
+			name:   "absolute path",
+			config: "Include " + filepath.Join(home, ".ssh", "abs") + "\n",
+			want:   []string{"absolute"},
+		},
+		{
+			name:   "several arguments",
+			config: "Include abs nested\n",
+			want:   []string{"absolute", "nested"},
+		},
+		{
+			name:   "missing files are skipped",
+			config: "Include does-not-exist conf.d/none-* abs\n",
+			want:   []string{"absolute"},
+		},
+		{
+			name:   "include inside a non-matching block is ignored",
+			config: "Host gitlab.com\n  Include abs\nHost github.com\n  IdentityFile main\n",
+			want:   []string{"main"},
+		},
+		{
+			name:   "include inside a matching block",
+			config: "Host github.com\n  Include abs\n  IdentityFile main\n",
+			want:   []string{"absolute", "main"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeSSHFile(t, home, "config", tt.config)
+			cfg, err := ParseConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cfg.GetAll("github.com", "IdentityFile"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetAll(IdentityFile) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateIncludeDepth(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// A chain longer than the limit: each file includes the next
+	const chain = maxIncludeDepth + 4
+	for i := 1; i <= chain; i++ {
+		writeSSHFile(t, home, fmt.Sprintf("level%d", i), fmt.Sprintf("IdentityFile key%d\nInclude level%d\n", i, i+1))
+	}
+	path := writeSSHFile(t, home, "config", "IdentityFile key0\nInclude level1\n")
+
+	cfg, err := ParseConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := cfg.GetAll("github.com", "IdentityFile")
+	if len(got) != maxIncludeDepth+1 {
+		t.Fatalf("read %d files (%q), want %d", len(got), got, maxIncludeDepth+1)
+	}
+	if last := got[len(got)-1]; last != fmt.Sprintf("key%d", maxIncludeDepth) {
+		t.Errorf("deepest file read = %s, want key%d", last, maxIncludeDepth)
+	}
+
+	// A file including itself stops at the same limit instead of looping
+	self := writeSSHFile(t, home, "self", "SendEnv LOOP\nInclude self\n")
+	cfg, err = ParseConfig(self)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.GetAll("github.com", "SendEnv"); len(got) != maxIncludeDepth+1 {
+		t.Errorf("self-include read %d times, want %d", len(got), maxIncludeDepth+1)
+	}
+}
@@ -0,0 +1,238 @@
+// ABOUTME: Live SSH probes for gh-context diagnostics
+// ABOUTME: Handshakes with git@<host> using one key and reads which account it authenticates as
+
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// ErrKeyRejected is returned when the host does not accept the key.
+var ErrKeyRejected = errors.New("key was not accepted")
+
+// PublicKeyPath returns the conventional public key path for a private key.
+func PublicKeyPath(keyPath string) string {
+	return ExpandPath(keyPath) + ".pub"
+}
+
+// greetingPattern matches GitHub's "Hi <login>! You've successfully authenticated" banner.
+var greetingPattern = regexp.MustCompile(`Hi ([^!\s]+)! You've successfully authenticated`)
+
+// Probe connects to a GitHub host as "git" with a single key, like
+// ssh -T -o IdentitiesOnly=yes, without running ssh.
+type Probe struct {
+	// Addr is the host:port to dial. Default: the HostName and Port that
+	// ~/.ssh/config gives the hostname, on port 22 unless set.
+	Addr string
+	// HostKeyCallback checks the server's host key. Default: the user's and
+	// the system's known_hosts files.
+	HostKeyCallback gossh.HostKeyCallback
+	// Timeout bounds the whole exchange. Default: 10 seconds.
+	Timeout time.Duration
+}
+
+// VerifyIdentity connects to git@hostname using only keyPath and returns the
+// account GitHub reports for it.
+func VerifyIdentity(hostname, keyPath string) (string, error) {
+	return (&Probe{}).Identity(hostname, keyPath)
+}
+
+// Identity connects to git@hostname using only keyPath and returns the
+// account GitHub reports for it. Returns an error wrapping ErrKeyRejected if
+// the host does not accept the key.
+func (p *Probe) Identity(hostname, keyPath string) (string, error) {
+	signer, err := loadSigner(keyPath)
+	if err != nil {
+		return "", err
+	}
+
+	addr := p.Addr
+	if addr == "" {
+		addr = defaultAddr(hostname)
+	}
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	hostKeyCallback := p.HostKeyCallback
+	if hostKeyCallback == nil {
+		if hostKeyCallback, err = knownHostsCallback(); err != nil {
+			return "", err
+		}
+	}
+
+	cfg := &gossh.ClientConfig{
+		User:            "git",
+		Auth:            []gossh.AuthMethod{gossh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	}
+
+	output, err := greet(addr, cfg, timeout)
+	var keyErr *knownhosts.KeyError
+	if errors.As(err, &keyErr) && len(keyErr.Want) > 0 && p.HostKeyCallback == nil {
+		// The server offered a host key type known_hosts has no entry for;
+		// retry asking only for the types it does have
+		cfg.HostKeyAlgorithms = knownKeyAlgorithms(keyErr.Want)
+		output, err = greet(addr, cfg, timeout)
+	}
+	if err != nil {
+		return "", describeProbeError(hostname, addr, err)
+	}
+
+	if match := greetingPattern.FindStringSubmatch(output); match != nil {
+		return match[1], nil
+	}
+	msg := strings.TrimSpace(output)
+	if msg == "" {
+		msg = "no greeting"
+	}
+	return "", fmt.Errorf("unexpected response from git@%s: %s", hostname, msg)
+}
+
+// greet performs the handshake and requests a session the way ssh -T does,
+// returning what the server printed.
+func greet(addr string, cfg *gossh.ClientConfig, timeout time.Duration) (string, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	c, chans, reqs, err := gossh.NewClientConn(conn, addr, cfg)
+	if err != nil {
+		return "", err
+	}
+	client := gossh.NewClient(c, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	// Separate buffers: the session copies both streams concurrently
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if err := session.Shell(); err != nil {
+		return "", err
+	}
+
+	// GitHub closes the session with exit status 1 after greeting, so the
+	// exit status says nothing about success; only the banner does
+	_ = session.Wait()
+	return stderr.String() + stdout.String(), nil
+}
+
+// describeProbeError turns handshake failures into actionable messages.
+func describeProbeError(hostname, addr string, err error) error {
+	var keyErr *knownhosts.KeyError
+	switch {
+	case errors.As(err, &keyErr) && len(keyErr.Want) == 0:
+		return fmt.Errorf("host key of %s is not in known_hosts; connect once with: ssh -T git@%s", addr, hostname)
+	case errors.As(err, &keyErr):
+		return fmt.Errorf("host key of %s does not match known_hosts (%s:%d); someone may be intercepting the connection",
+			addr, keyErr.Want[0].Filename, keyErr.Want[0].Line)
+	case strings.Contains(err.Error(), "unable to authenticate"):
+		return fmt.Errorf("ssh to git@%s failed: %w", hostname, ErrKeyRejected)
+	default:
+		return fmt.Errorf("ssh to git@%s failed: %v", hostname, err)
+	}
+}
+
+// loadSigner reads the private key at keyPath. Passphrase-protected keys are
+// used through ssh-agent, if it holds them.
+func loadSigner(keyPath string) (gossh.Signer, error) {
+	data, err := os.ReadFile(ExpandPath(keyPath))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read SSH key: %w", err)
+	}
+
+	signer, err := gossh.ParsePrivateKey(data)
+	var missing *gossh.PassphraseMissingError
+	if !errors.As(err, &missing) {
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse SSH key %s: %w", keyPath, err)
+		}
+		return signer, nil
+	}
+
+	agent, err := ConnectAgent()
+	if err != nil {
+		return nil, fmt.Errorf("SSH key %s is passphrase-protected; load it with: ssh-add %s", keyPath, keyPath)
+	}
+	pub := missing.PublicKey
+	if pub == nil {
+		if pub, err = loadPublicKey(keyPath); err != nil {
+			agent.Close()
+			return nil, err
+		}
+	}
+	// The connection stays open for the signer; the process is short-lived
+	signer, err = agent.Signer(pub)
+	if err != nil {
+		agent.Close()
+		return nil, fmt.Errorf("SSH key %s is passphrase-protected; load it with: ssh-add %s", keyPath, keyPath)
+	}
+	return signer, nil
+}
+
+// defaultAddr returns the address ssh would dial for hostname.
+func defaultAddr(hostname string) string {
+	host, port := hostname, "22"
+	if cfg, err := ParseConfig(""); err == nil {
+		if h := cfg.Get(hostname, "hostname"); h != "" {
+			host = h
+		}
+		if p := cfg.Get(hostname, "port"); p != "" {
+			port = p
+		}
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// knownHostsCallback checks host keys against the known_hosts files that exist.
+func knownHostsCallback() (gossh.HostKeyCallback, error) {
+	var files []string
+	candidates := []string{filepath.FromSlash("/etc/ssh/ssh_known_hosts")}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append([]string{filepath.Join(home, ".ssh", "known_hosts")}, candidates...)
+	}
+	for _, f := range candidates {
+		if _, err := os.Stat(f); err == nil {
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no known_hosts file; connect once with ssh to record the host key")
+	}
+	return knownhosts.New(files...)
+}
+
+// knownKeyAlgorithms lists the host key algorithms matching known keys.
+func knownKeyAlgorithms(known []knownhosts.KnownKey) []string {
+	var algos []string
+	for _, k := range known {
+		switch t := k.Key.Type(); t {
+		case gossh.KeyAlgoRSA:
+			algos = append(algos, gossh.KeyAlgoRSASHA512, gossh.KeyAlgoRSASHA256, gossh.KeyAlgoRSA)
+		default:
+			algos = append(algos, t)
+		}
+	}
+	return algos
+}
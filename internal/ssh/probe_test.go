@@ -0,0 +1,176 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// fakeGitHub is an in-process stand-in for GitHub's SSH endpoint: it accepts
+// the keys in logins and greets like GitHub does.
+type fakeGitHub struct {
+	addr    string
+	hostKey gossh.Signer
+	logins  map[string]string // Marshaled public key -> account
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := gossh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeGitHub{addr: ln.Addr().String(), hostKey: hostKey, logins: make(map[string]string)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// register makes the server accept pub as login.
+func (s *fakeGitHub) register(pub gossh.PublicKey, login string) {
+	s.logins[string(pub.Marshal())] = login
+}
+
+func (s *fakeGitHub) serve(conn net.Conn) {
+	defer conn.Close()
+	cfg := &gossh.ServerConfig{
+		PublicKeyCallback: func(meta gossh.ConnMetadata, key gossh.PublicKey) (*gossh.Permissions, error) {
+			login, ok := s.logins[string(key.Marshal())]
+			if !ok || meta.User() != "git" {
+				return nil, fmt.Errorf("unknown key")
+			}
+			return &gossh.Permissions{Extensions: map[string]string{"login": login}}, nil
+		},
+	}
+	cfg.AddHostKey(s.hostKey)
+
+	sconn, chans, reqs, err := gossh.NewServerConn(conn, cfg)
+	if err != nil {
+		return
+	}
+	defer sconn.Close()
+	go gossh.DiscardRequests(reqs)
+
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			_ = newChan.Reject(gossh.UnknownChannelType, "no")
+			continue
+		}
+		ch, chReqs, err := newChan.Accept()
+		if err != nil {
+			return
+		}
+		for req := range chReqs {
+			if req.Type != "shell" {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = req.Reply(true, nil)
+			fmt.Fprintf(ch.Stderr(), "Hi %s! You've successfully authenticated, but GitHub does not provide shell access.\n",
+				sconn.Permissions.Extensions["login"])
+			_, _ = ch.SendRequest("exit-status", false, gossh.Marshal(struct{ Status uint32 }{1}))
+			ch.Close()
+			break
+		}
+	}
+}
+
+func TestProbeIdentity(t *testing.T) {
+	server := newFakeGitHub(t)
+	dir := t.TempDir()
+	workKey, workPub := writeTestKey(t, dir, "id_work", "")
+	otherKey, otherPub := writeTestKey(t, dir, "id_other", "")
+	strayKey, _ := writeTestKey(t, dir, "id_stray", "")
+	server.register(workPub, "work-user")
+	server.register(otherPub, "someone-else")
+
+	probe := &Probe{
+		Addr:            server.addr,
+		HostKeyCallback: gossh.FixedHostKey(server.hostKey.PublicKey()),
+		Timeout:         5 * time.Second,
+	}
+
+	if login, err := probe.Identity("github.com", workKey); err != nil || login != "work-user" {
+		t.Errorf("registered key: login = %q, %v; want work-user", login, err)
+	}
+	// A mismatch is not an error for the probe; callers compare the login
+	if login, err := probe.Identity("github.com", otherKey); err != nil || login != "someone-else" {
+		t.Errorf("other account's key: login = %q, %v; want someone-else", login, err)
+	}
+	if _, err := probe.Identity("github.com", strayKey); !errors.Is(err, ErrKeyRejected) {
+		t.Errorf("unregistered key: error = %v, want ErrKeyRejected", err)
+	}
+}
+
+func TestProbeChecksKnownHosts(t *testing.T) {
+	server := newFakeGitHub(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	keyPath, pub := writeTestKey(t, home, "id_work", "")
+	server.register(pub, "work-user")
+
+	knownHosts := filepath.Join(home, ".ssh", "known_hosts")
+	if err := os.MkdirAll(filepath.Dir(knownHosts), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeKnownHosts := func(key gossh.PublicKey) {
+		t.Helper()
+		var data []byte
+		if key != nil {
+			data = []byte(knownhosts.Line([]string{knownhosts.Normalize(server.addr)}, key) + "\n")
+		}
+		if err := os.WriteFile(knownHosts, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	probe := &Probe{Addr: server.addr, Timeout: 5 * time.Second}
+
+	writeKnownHosts(nil)
+	if _, err := probe.Identity("github.com", keyPath); err == nil || !strings.Contains(err.Error(), "not in known_hosts") {
+		t.Errorf("unknown host: error = %v, want a 'not in known_hosts' error", err)
+	}
+
+	_, otherHost, _ := ed25519.GenerateKey(rand.Reader)
+	impostor, err := gossh.NewSignerFromKey(otherHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKnownHosts(impostor.PublicKey())
+	if _, err := probe.Identity("github.com", keyPath); err == nil || !strings.Contains(err.Error(), "does not match known_hosts") {
+		t.Errorf("changed host key: error = %v, want a 'does not match' error", err)
+	}
+
+	writeKnownHosts(server.hostKey.PublicKey())
+	if login, err := probe.Identity("github.com", keyPath); err != nil || login != "work-user" {
+		t.Errorf("known host: login = %q, %v; want work-user", login, err)
+	}
+}
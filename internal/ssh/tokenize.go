@@ -0,0 +1,98 @@
+// ABOUTME: ssh_config line tokenizer for gh-context
+// ABOUTME: Splits lines into keyword and arguments the way OpenSSH's readconf does
+
+package ssh
+
+import "strings"
+
+// Directive is a single keyword line from an SSH config file.
+type Directive struct {
+	File    string   // Path of the file the directive came from
+	Line    int      // Index into that file's Lines (0-indexed)
+	Keyword string   // Lowercased keyword (e.g., "identityfile")
+	Args    []string // Arguments with quotes removed
+}
+
+// Value returns the directive's arguments joined by single spaces.
+func (d Directive) Value() string {
+	return strings.Join(d.Args, " ")
+}
+
+// tokenizeLine splits a config line into a lowercased keyword and its arguments.
+// Keywords may be separated from arguments by whitespace or a single '='.
+// Double quotes group arguments containing spaces, and an unquoted argument
+// starting with '#' begins a trailing comment.
+// Returns ok=false for blank and comment lines.
+func tokenizeLine(line string) (keyword string, args []string, ok bool) {
+	s := strings.TrimLeft(line, " \t")
+	s = strings.TrimRight(s, " \t\r")
+	if s == "" || s[0] == '#' {
+		return "", nil, false
+	}
+
+	end := strings.IndexAny(s, " \t=")
+	if end < 0 {
+		return strings.ToLower(s), nil, true
+	}
+	keyword = strings.ToLower(s[:end])
+
+	// Skip the separator: whitespace, at most one '=', whitespace
+	rest := strings.TrimLeft(s[end:], " \t")
+	if strings.HasPrefix(rest, "=") {
+		rest = strings.TrimLeft(rest[1:], " \t")
+	}
+
+	return keyword, splitArgs(rest), true
+}
+
+// splitArgs splits the argument part of a config line.
+func splitArgs(s string) []string {
+	var args []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return args
+		}
+
+		if s[0] == '"' {
+			closing := strings.IndexByte(s[1:], '"')
+			if closing < 0 {
+				// Unterminated quote; take the rest of the line
+				return append(args, s[1:])
+			}
+			args = append(args, s[1:closing+1])
+			s = s[closing+2:]
+			continue
+		}
+
+		if s[0] == '#' {
+			return args // Trailing comment
+		}
+
+		end := strings.IndexAny(s, " \t")
+		if end < 0 {
+			return append(args, s)
+		}
+		args = append(args, s[:end])
+		s = s[end:]
+	}
+}
+
+// tokenizeCommentedLine parses a commented-out directive such as
+// "# IdentityFile ~/.ssh/id_work". Returns ok=false if the line is not a comment.
+func tokenizeCommentedLine(line string) (keyword string, args []string, ok bool) {
+	s := strings.TrimLeft(line, " \t")
+	if !strings.HasPrefix(s, "#") {
+		return "", nil, false
+	}
+	s = strings.TrimLeft(s, "#")
+	return tokenizeLine(s)
+}
+
+// quoteArg double-quotes an argument if it contains whitespace.
+func quoteArg(s string) string {
+	if strings.ContainsAny(s, " \t") {
+		return `"` + s + `"`
+	}
+	return s
+}
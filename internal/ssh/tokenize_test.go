@@ -0,0 +1,66 @@
+package ssh
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenizeLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		keyword string
+		args    []string
+		ok      bool
+	}{
+		{"", "", nil, false},
+		{"   \t", "", nil, false},
+		{"# IdentityFile ~/.ssh/id_work", "", nil, false},
+		{"  # indented comment", "", nil, false},
+		{"Host github.com", "host", []string{"github.com"}, true},
+		{"  IdentityFile ~/.ssh/id_work\r", "identityfile", []string{"~/.ssh/id_work"}, true},
+		{"\tHostName\tgithub.com", "hostname", []string{"github.com"}, true},
+		{"IdentityFile=~/.ssh/id_work", "identityfile", []string{"~/.ssh/id_work"}, true},
+		{"IdentityFile = ~/.ssh/id_work", "identityfile", []string{"~/.ssh/id_work"}, true},
+		{"IdentityFile =~/.ssh/id_work", "identityfile", []string{"~/.ssh/id_work"}, true},
+		{`IdentityFile "~/My Keys/id_work"`, "identityfile", []string{"~/My Keys/id_work"}, true},
+		{`IdentityFile "~/unterminated key`, "identityfile", []string{"~/unterminated key"}, true},
+		{"Host a b !c # trailing comment", "host", []string{"a", "b", "!c"}, true},
+		{"Host a#b", "host", []string{"a#b"}, true},
+		{"Match host github.com user git", "match", []string{"host", "github.com", "user", "git"}, true},
+		{"ForwardAgent", "forwardagent", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			keyword, args, ok := tokenizeLine(tt.line)
+			if keyword != tt.keyword || ok != tt.ok || !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("tokenizeLine(%q) = %q, %q, %v; want %q, %q, %v",
+					tt.line, keyword, args, ok, tt.keyword, tt.args, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTokenizeCommentedLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		keyword string
+		args    []string
+		ok      bool
+	}{
+		{"# IdentityFile ~/.ssh/id_work", "identityfile", []string{"~/.ssh/id_work"}, true},
+		{"  ##IdentityFile=~/.ssh/id_work", "identityfile", []string{"~/.ssh/id_work"}, true},
+		{"IdentityFile ~/.ssh/id_work", "", nil, false},
+		{"#", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			keyword, args, ok := tokenizeCommentedLine(tt.line)
+			if keyword != tt.keyword || ok != tt.ok || !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("tokenizeCommentedLine(%q) = %q, %q, %v; want %q, %q, %v",
+					tt.line, keyword, args, ok, tt.keyword, tt.args, tt.ok)
+			}
+		})
+	}
+}